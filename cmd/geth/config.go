@@ -97,6 +97,7 @@ type gethConfig struct {
 	Node     node.Config
 	Ethstats ethstatsConfig
 	Metrics  metrics.Config
+	Sealer   sealer.SealerConfig
 }
 
 func loadConfig(file string, cfg *gethConfig) error {
@@ -133,6 +134,7 @@ func loadBaseConfig(ctx *cli.Context) gethConfig {
 		Eth:     ethconfig.Defaults,
 		Node:    defaultNodeConfig(),
 		Metrics: metrics.DefaultConfig,
+		Sealer:  sealer.DefaultConfig,
 	}
 
 	// Load config file.
@@ -164,6 +166,7 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 		cfg.Ethstats.URL = ctx.String(utils.EthStatsURLFlag.Name)
 	}
 	applyMetricConfig(ctx, &cfg)
+	applySealerConfig(ctx, &cfg)
 
 	return stack, cfg
 }
@@ -180,13 +183,7 @@ func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 		cfg.Eth.OverrideVerkle = &v
 	}
 
-	sealerConfig := &sealer.SealerConfig{}
-	if ctx.IsSet(utils.SealerEnabled.Name) {
-		sealerConfig.Enabled = ctx.Bool(utils.SealerEnabled.Name)
-	}
-	if ctx.IsSet(utils.SealerIsInsecure.Name) {
-		sealerConfig.Insecure = ctx.Bool(utils.SealerIsInsecure.Name)
-	}
+	sealerConfig := &cfg.Sealer
 
 	backend, eth := utils.RegisterEthService(stack, &cfg.Eth)
 
@@ -281,6 +278,53 @@ func dumpConfig(ctx *cli.Context) error {
 	return nil
 }
 
+func applySealerConfig(ctx *cli.Context, cfg *gethConfig) {
+	if ctx.IsSet(utils.SealerEnabled.Name) {
+		cfg.Sealer.Enabled = ctx.Bool(utils.SealerEnabled.Name)
+	}
+	if ctx.IsSet(utils.SealerIsInsecure.Name) {
+		cfg.Sealer.Insecure = ctx.Bool(utils.SealerIsInsecure.Name)
+	}
+	if ctx.IsSet(utils.SealerExtraDataFlag.Name) {
+		extra, err := hexutil.Decode(ctx.String(utils.SealerExtraDataFlag.Name))
+		if err != nil {
+			utils.Fatalf("Invalid sealer extra data: %v", err)
+		}
+		cfg.Sealer.ExtraData = extra
+	}
+	if ctx.IsSet(utils.SealerMaxTxsFlag.Name) {
+		cfg.Sealer.MaxTxsPerRequest = ctx.Int(utils.SealerMaxTxsFlag.Name)
+	}
+	if ctx.IsSet(utils.SealerMaxStateReadsFlag.Name) {
+		cfg.Sealer.MaxStateReads = ctx.Int(utils.SealerMaxStateReadsFlag.Name)
+	}
+	if ctx.IsSet(utils.SealerTimeoutFlag.Name) {
+		cfg.Sealer.SealTimeout = ctx.Duration(utils.SealerTimeoutFlag.Name)
+	}
+	if ctx.IsSet(utils.SealerTraceTimeoutFlag.Name) {
+		cfg.Sealer.TraceTimeout = ctx.Duration(utils.SealerTraceTimeoutFlag.Name)
+	}
+	if ctx.IsSet(utils.SealerCoinbasesFlag.Name) {
+		cfg.Sealer.AllowedCoinbases = nil
+		for _, addr := range strings.Split(ctx.String(utils.SealerCoinbasesFlag.Name), ",") {
+			if addr = strings.TrimSpace(addr); !common.IsHexAddress(addr) {
+				utils.Fatalf("Invalid sealer coinbase %q", addr)
+			}
+			cfg.Sealer.AllowedCoinbases = append(cfg.Sealer.AllowedCoinbases, common.HexToAddress(addr))
+		}
+	}
+	// Move toward the gas ceiling of the miner unless the sealer has its own.
+	if cfg.Sealer.GasCeil == 0 {
+		cfg.Sealer.GasCeil = cfg.Eth.Miner.GasCeil
+	}
+	if ctx.IsSet(utils.SealerMaxConcurrentFlag.Name) {
+		cfg.Sealer.MaxConcurrentSeals = ctx.Int(utils.SealerMaxConcurrentFlag.Name)
+	}
+	if ctx.IsSet(utils.SealerSealedBlocksFlag.Name) {
+		cfg.Sealer.SealedBlocks = ctx.Int(utils.SealerSealedBlocksFlag.Name)
+	}
+}
+
 func applyMetricConfig(ctx *cli.Context, cfg *gethConfig) {
 	if ctx.IsSet(utils.MetricsEnabledFlag.Name) {
 		cfg.Metrics.Enabled = ctx.Bool(utils.MetricsEnabledFlag.Name)
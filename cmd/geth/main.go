@@ -198,6 +198,14 @@ var (
 	sealerFlags = []cli.Flag{
 		utils.SealerEnabled,
 		utils.SealerIsInsecure,
+		utils.SealerExtraDataFlag,
+		utils.SealerMaxTxsFlag,
+		utils.SealerMaxStateReadsFlag,
+		utils.SealerTimeoutFlag,
+		utils.SealerTraceTimeoutFlag,
+		utils.SealerCoinbasesFlag,
+		utils.SealerMaxConcurrentFlag,
+		utils.SealerSealedBlocksFlag,
 	}
 )
 
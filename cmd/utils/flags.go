@@ -38,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/fdlimit"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
@@ -70,6 +71,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/netutil"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/sealer"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/trie/triedb/hashdb"
 	"github.com/ethereum/go-ethereum/trie/triedb/pathdb"
@@ -945,12 +947,61 @@ Please note that --` + MetricsHTTPFlag.Name + ` must be set to start the server.
 		Category: flags.MetricsCategory,
 	}
 	SealerEnabled = &cli.BoolFlag{
-		Name:  "sealer",
-		Usage: "Enable the sealer API",
+		Name:     "sealer",
+		Usage:    "Enable the sealer API",
+		Category: flags.SealerCategory,
 	}
 	SealerIsInsecure = &cli.BoolFlag{
-		Name:  "sealer.insecure",
-		Usage: "Enable the sealer API via not authenticated endpoint(s)",
+		Name:     "sealer.insecure",
+		Usage:    "Enable the sealer API via not authenticated endpoint(s)",
+		Category: flags.SealerCategory,
+	}
+	SealerExtraDataFlag = &cli.StringFlag{
+		Name:     "sealer.extradata",
+		Usage:    "Hex encoded extra data of the sealed blocks when the request has none",
+		Value:    hexutil.Encode(sealer.DefaultConfig.ExtraData),
+		Category: flags.SealerCategory,
+	}
+	SealerMaxTxsFlag = &cli.IntFlag{
+		Name:     "sealer.maxtxs",
+		Usage:    "Maximum number of caller transactions per seal request",
+		Value:    sealer.DefaultConfig.MaxTxsPerRequest,
+		Category: flags.SealerCategory,
+	}
+	SealerMaxStateReadsFlag = &cli.IntFlag{
+		Name:     "sealer.maxstatereads",
+		Usage:    "Maximum number of accounts and storage slots per bulk state request",
+		Value:    sealer.DefaultConfig.MaxStateReads,
+		Category: flags.SealerCategory,
+	}
+	SealerTimeoutFlag = &cli.DurationFlag{
+		Name:     "sealer.timeout",
+		Usage:    "Time after which no more transactions are added to a sealed block",
+		Value:    sealer.DefaultConfig.SealTimeout,
+		Category: flags.SealerCategory,
+	}
+	SealerTraceTimeoutFlag = &cli.DurationFlag{
+		Name:     "sealer.tracetimeout",
+		Usage:    "Default and maximum time a tracer may spend on one sealed transaction",
+		Value:    sealer.DefaultConfig.TraceTimeout,
+		Category: flags.SealerCategory,
+	}
+	SealerCoinbasesFlag = &cli.StringFlag{
+		Name:     "sealer.coinbases",
+		Usage:    "Comma separated coinbases that blocks may be sealed for (default = any)",
+		Category: flags.SealerCategory,
+	}
+	SealerMaxConcurrentFlag = &cli.IntFlag{
+		Name:     "sealer.maxconcurrent",
+		Usage:    "Maximum number of seal requests executed at the same time",
+		Value:    sealer.DefaultConfig.MaxConcurrentSeals,
+		Category: flags.SealerCategory,
+	}
+	SealerSealedBlocksFlag = &cli.IntFlag{
+		Name:     "sealer.sealedblocks",
+		Usage:    "Number of sealed blocks kept with their state to validate, import or seal on top of",
+		Value:    sealer.DefaultConfig.SealedBlocks,
+		Category: flags.SealerCategory,
 	}
 )
 
@@ -314,6 +314,8 @@ type BlobPool struct {
 	discoverFeed event.Feed // Event feed to send out new tx events on pool discovery (reorg excluded)
 	insertFeed   event.Feed // Event feed to send out new tx events on pool inclusion (reorg included)
 
+	eventFeed txpool.TxEventFeed // Event feed to send out the transitions of the pooled transactions
+
 	lock sync.RWMutex // Mutex protecting the pool during reorg handling
 }
 
@@ -433,6 +435,9 @@ func (p *BlobPool) Init(gasTip *big.Int, head *types.Header, reserve txpool.Addr
 
 // Close closes down the underlying persistent store.
 func (p *BlobPool) Close() error {
+	// Unsubscribe anyone still listening for tx events
+	p.eventFeed.Close()
+
 	var errs []error
 	if err := p.limbo.Close(); err != nil {
 		errs = append(errs, err)
@@ -520,6 +525,12 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			ids = append(ids, txs[i].id)
 			nonces = append(nonces, txs[i].nonce)
 
+			if gapped {
+				p.queueEvent(txs[i].id, txpool.TxEvent{Type: txpool.TxEventDropped, Reason: txpool.TxDropNonceGap})
+			} else {
+				p.queueStaleEvent(txs[i], inclusions)
+			}
+
 			p.stored -= uint64(txs[i].size)
 			delete(p.lookup, txs[i].hash)
 
@@ -557,6 +568,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 		for txs[0].nonce < next {
 			ids = append(ids, txs[0].id)
 			nonces = append(nonces, txs[0].nonce)
+			p.queueStaleEvent(txs[0], inclusions)
 
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[0].costCap)
 			p.stored -= uint64(txs[0].size)
@@ -613,6 +625,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 		for j := i; j < len(txs); j++ {
 			ids = append(ids, txs[j].id)
 			nonces = append(nonces, txs[j].nonce)
+			p.queueEvent(txs[j].id, txpool.TxEvent{Type: txpool.TxEventDropped, Reason: txpool.TxDropNonceGap})
 
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[j].costCap)
 			p.stored -= uint64(txs[j].size)
@@ -649,6 +662,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 
 			ids = append(ids, last.id)
 			nonces = append(nonces, last.nonce)
+			p.queueEvent(last.id, txpool.TxEvent{Type: txpool.TxEventDropped, Reason: txpool.TxDropUnpayable})
 
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], last.costCap)
 			p.stored -= uint64(last.size)
@@ -687,6 +701,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 
 			ids = append(ids, last.id)
 			nonces = append(nonces, last.nonce)
+			p.queueEvent(last.id, txpool.TxEvent{Type: txpool.TxEventDropped, Reason: txpool.TxDropOverflow})
 
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], last.costCap)
 			p.stored -= uint64(last.size)
@@ -708,6 +723,39 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 	}
 }
 
+// queueEvent schedules an event for the subscribers about the transaction stored
+// under the given id, filling in the transaction and its sender. It needs to be
+// called before the transaction is deleted from the store.
+func (p *BlobPool) queueEvent(id uint64, ev txpool.TxEvent) {
+	if !p.eventFeed.Active() {
+		return
+	}
+	data, err := p.store.Get(id)
+	if err != nil {
+		log.Error("Blobs missing for pooled transaction", "id", id, "err", err)
+		return
+	}
+	var tx types.Transaction
+	if err = rlp.DecodeBytes(data, &tx); err != nil {
+		log.Error("Blobs corrupted for pooled transaction", "id", id, "err", err)
+		return
+	}
+	ev.Tx = tx.WithoutBlobTxSidecar()
+	ev.From, _ = types.Sender(p.signer, &tx)
+	p.eventFeed.Queue(ev)
+}
+
+// queueStaleEvent schedules an event for a transaction whose nonce was used up
+// by the chain: an included event if the transaction itself made it into a
+// block, a dropped one otherwise.
+func (p *BlobPool) queueStaleEvent(meta *blobTxMeta, inclusions map[common.Hash]uint64) {
+	if _, ok := inclusions[meta.hash]; ok {
+		p.queueEvent(meta.id, txpool.TxEvent{Type: txpool.TxEventIncluded})
+	} else {
+		p.queueEvent(meta.id, txpool.TxEvent{Type: txpool.TxEventDropped, Reason: txpool.TxDropNonceTooLow})
+	}
+}
+
 // offload removes a tracked blob transaction from the pool and moves it into the
 // limbo for tracking until finality.
 //
@@ -740,6 +788,8 @@ func (p *BlobPool) offload(addr common.Address, nonce uint64, id uint64, inclusi
 // Reset implements txpool.SubPool, allowing the blob pool's internal state to be
 // kept in sync with the main transacion pool's internal state.
 func (p *BlobPool) Reset(oldHead, newHead *types.Header) {
+	defer p.eventFeed.Flush()
+
 	waitStart := time.Now()
 	p.lock.Lock()
 	resetwaitHist.Update(time.Since(waitStart).Nanoseconds())
@@ -766,6 +816,7 @@ func (p *BlobPool) Reset(oldHead, newHead *types.Header) {
 			for _, tx := range txs {
 				if err := p.reinject(addr, tx.Hash()); err == nil {
 					adds = append(adds, tx.WithoutBlobTxSidecar())
+					p.eventFeed.Queue(txpool.TxEvent{Type: txpool.TxEventAdded, Tx: adds[len(adds)-1], From: addr, Pending: true})
 				}
 			}
 			// Recheck the account's pooled transactions to drop included and
@@ -974,6 +1025,8 @@ func (p *BlobPool) reinject(addr common.Address, txhash common.Hash) error {
 // SetGasTip implements txpool.SubPool, allowing the blob pool's gas requirements
 // to be kept in sync with the main transacion pool's gas requirements.
 func (p *BlobPool) SetGasTip(tip *big.Int) {
+	defer p.eventFeed.Flush()
+
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
@@ -991,6 +1044,7 @@ func (p *BlobPool) SetGasTip(tip *big.Int) {
 						ids    = []uint64{tx.id}
 						nonces = []uint64{tx.nonce}
 					)
+					p.queueEvent(tx.id, txpool.TxEvent{Type: txpool.TxEventDropped, Reason: txpool.TxDropUnderpriced})
 					p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[i].costCap)
 					p.stored -= uint64(tx.size)
 					delete(p.lookup, tx.hash)
@@ -1000,6 +1054,7 @@ func (p *BlobPool) SetGasTip(tip *big.Int) {
 					for j, tx := range txs[i+1:] {
 						ids = append(ids, tx.id)
 						nonces = append(nonces, tx.nonce)
+						p.queueEvent(tx.id, txpool.TxEvent{Type: txpool.TxEventDropped, Reason: txpool.TxDropUnderpriced})
 
 						p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], tx.costCap)
 						p.stored -= uint64(tx.size)
@@ -1173,6 +1228,8 @@ func (p *BlobPool) Add(txs []*types.Transaction, local bool, sync bool) []error
 			adds = append(adds, tx.WithoutBlobTxSidecar())
 		}
 	}
+	p.eventFeed.Flush()
+
 	if len(adds) > 0 {
 		p.discoverFeed.Send(core.NewTxsEvent{Txs: adds})
 		p.insertFeed.Send(core.NewTxsEvent{Txs: adds})
@@ -1245,6 +1302,7 @@ func (p *BlobPool) add(tx *types.Transaction) (err error) {
 	if len(p.index[from]) > offset {
 		// Transaction replaces a previously queued one
 		prev := p.index[from][offset]
+		p.queueEvent(prev.id, txpool.TxEvent{Type: txpool.TxEventReplaced, ReplacedBy: meta.hash})
 		if err := p.store.Delete(prev.id); err != nil {
 			// Shitty situation, but try to recover gracefully instead of going boom
 			log.Error("Failed to delete replaced transaction", "id", prev.id, "err", err)
@@ -1268,6 +1326,9 @@ func (p *BlobPool) add(tx *types.Transaction) (err error) {
 		p.lookup[meta.hash] = meta.id
 		p.stored += uint64(meta.size)
 	}
+	if p.eventFeed.Active() {
+		p.eventFeed.Queue(txpool.TxEvent{Type: txpool.TxEventAdded, Tx: tx.WithoutBlobTxSidecar(), From: from, Pending: true})
+	}
 	// Recompute the rolling eviction fields. In case of a replacement, this will
 	// recompute all subsequent fields. In case of an append, this will only do
 	// the fresh calculation.
@@ -1372,6 +1433,7 @@ func (p *BlobPool) drop() {
 	}
 	// Remove the transaction from the data store
 	log.Warn("Evicting overflown blob transaction", "from", from, "evicted", drop.nonce, "id", drop.id)
+	p.queueEvent(drop.id, txpool.TxEvent{Type: txpool.TxEventDropped, Reason: txpool.TxDropOverflow})
 	if err := p.store.Delete(drop.id); err != nil {
 		log.Error("Failed to drop evicted transaction", "id", drop.id, "err", err)
 	}
@@ -1499,6 +1561,12 @@ func (p *BlobPool) SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs bool
 	}
 }
 
+// SubscribeEvents registers a subscription for the transitions of the pooled
+// transactions.
+func (p *BlobPool) SubscribeEvents(ch chan<- []txpool.TxEvent) event.Subscription {
+	return p.eventFeed.Subscribe(ch)
+}
+
 // Nonce returns the next nonce of an account, with all transactions executable
 // by the pool already applied on top.
 func (p *BlobPool) Nonce(addr common.Address) uint64 {
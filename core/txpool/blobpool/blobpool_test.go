@@ -1249,3 +1249,77 @@ func TestAdd(t *testing.T) {
 		pool.Close()
 	}
 }
+
+// Tests that the transitions of the pooled blob transactions are announced on
+// the event feed, without the blob sidecars.
+func TestTxEvents(t *testing.T) {
+	storage, _ := os.MkdirTemp("", "blobpool-")
+	defer os.RemoveAll(storage)
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewDatabase(memorydb.New())), nil)
+	statedb.AddBalance(from, big.NewInt(params.Ether))
+	statedb.Commit(0, true)
+
+	chain := &testBlockChain{
+		config:  testChainConfig,
+		basefee: uint256.NewInt(1050),
+		blobfee: uint256.NewInt(105),
+		statedb: statedb,
+	}
+	pool := New(Config{Datadir: storage}, chain)
+	if err := pool.Init(big.NewInt(1), chain.CurrentBlock(), makeAddressReserver()); err != nil {
+		t.Fatalf("failed to create blob pool: %v", err)
+	}
+	defer pool.Close()
+
+	events := make(chan []txpool.TxEvent, 16)
+	sub := pool.SubscribeEvents(events)
+	defer sub.Unsubscribe()
+
+	// Add a transaction, replace it and drop the replacement by raising the tip
+	var (
+		tx          = makeTx(0, 1, 1, 1, key)
+		replacement = makeTx(0, 2, 2, 2, key)
+	)
+	if errs := pool.Add([]*types.Transaction{tx}, false, true); errs[0] != nil {
+		t.Fatalf("failed to add transaction: %v", errs[0])
+	}
+	if errs := pool.Add([]*types.Transaction{replacement}, false, true); errs[0] != nil {
+		t.Fatalf("failed to replace transaction: %v", errs[0])
+	}
+	pool.SetGasTip(big.NewInt(3))
+
+	want := []txpool.TxEvent{
+		{Type: txpool.TxEventAdded, Tx: tx, Pending: true},
+		{Type: txpool.TxEventReplaced, Tx: tx, ReplacedBy: replacement.Hash()},
+		{Type: txpool.TxEventAdded, Tx: replacement, Pending: true},
+		{Type: txpool.TxEventDropped, Tx: replacement, Reason: txpool.TxDropUnderpriced},
+	}
+	var received []txpool.TxEvent
+	for len(received) < len(want) {
+		select {
+		case evs := <-events:
+			received = append(received, evs...)
+		case <-time.After(time.Second):
+			t.Fatalf("event #%d not fired", len(received))
+		}
+	}
+	if len(received) != len(want) {
+		t.Fatalf("event count mismatch: have %d, want %d", len(received), len(want))
+	}
+	for i, ev := range received {
+		if ev.Type != want[i].Type || ev.Tx.Hash() != want[i].Tx.Hash() || ev.From != from {
+			t.Errorf("event #%d mismatch: have %v of %x from %x, want %v of %x from %x", i, ev.Type, ev.Tx.Hash(), ev.From, want[i].Type, want[i].Tx.Hash(), from)
+		}
+		if ev.Pending != want[i].Pending || ev.ReplacedBy != want[i].ReplacedBy || ev.Reason != want[i].Reason {
+			t.Errorf("event #%d details mismatch: have %+v, want %+v", i, ev, want[i])
+		}
+		if ev.Tx.BlobTxSidecar() != nil {
+			t.Errorf("event #%d: blob sidecar not stripped", i)
+		}
+	}
+	verifyPoolInternals(t, pool)
+}
@@ -0,0 +1,233 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// TxEventType is the kind of transition a pooled transaction went through.
+type TxEventType uint
+
+const (
+	TxEventAdded    TxEventType = iota // Transaction accepted into the pool
+	TxEventPromoted                    // Transaction moved from queued to pending
+	TxEventDemoted                     // Transaction moved from pending back to queued
+	TxEventReplaced                    // Transaction replaced by another with the same nonce
+	TxEventDropped                     // Transaction removed from the pool without inclusion
+	TxEventIncluded                    // Transaction removed from the pool due to inclusion
+)
+
+// String implements fmt.Stringer.
+func (t TxEventType) String() string {
+	switch t {
+	case TxEventAdded:
+		return "added"
+	case TxEventPromoted:
+		return "promoted"
+	case TxEventDemoted:
+		return "demoted"
+	case TxEventReplaced:
+		return "replaced"
+	case TxEventDropped:
+		return "dropped"
+	case TxEventIncluded:
+		return "included"
+	default:
+		return "unknown"
+	}
+}
+
+// TxDropReason is the reason a transaction was dropped from the pool.
+type TxDropReason uint
+
+const (
+	TxDropUnderpriced TxDropReason = iota // Tip below the pool minimum, or outbid when full
+	TxDropNonceTooLow                     // Nonce used up by a different transaction
+	TxDropNonceGap                        // Nonce gap the pool does not keep around
+	TxDropUnpayable                       // Sender balance or block gas limit too low
+	TxDropOverflow                        // Pool or account capacity exceeded
+	TxDropEvicted                         // Queued for longer than the pool lifetime
+)
+
+// String implements fmt.Stringer.
+func (r TxDropReason) String() string {
+	switch r {
+	case TxDropUnderpriced:
+		return "underpriced"
+	case TxDropNonceTooLow:
+		return "nonceTooLow"
+	case TxDropNonceGap:
+		return "nonceGap"
+	case TxDropUnpayable:
+		return "unpayable"
+	case TxDropOverflow:
+		return "overflow"
+	case TxDropEvicted:
+		return "evicted"
+	default:
+		return "unknown"
+	}
+}
+
+// TxEvent is a transition of a single transaction within a subpool.
+type TxEvent struct {
+	Type TxEventType
+	Tx   *types.Transaction // Transaction the event is about, without blob sidecar
+	From common.Address     // Sender of the transaction
+
+	Pending    bool         // Whether an added transaction is executable
+	ReplacedBy common.Hash  // Hash of the replacing transaction, for replaced events
+	Reason     TxDropReason // Reason of the removal, for dropped events
+}
+
+// maxTxEventBacklog is the number of event batches kept for a subscriber that
+// has not received them yet. A subscriber falling further behind is dropped.
+const maxTxEventBacklog = 1024
+
+// ErrTxEventsOverflow is returned on the subscription of a subscriber dropped
+// for not keeping up with the events of the pool.
+var ErrTxEventsOverflow = errors.New("transaction events overflow")
+
+// TxEventFeed collects the events of a subpool while its lock is held and sends
+// them out in order once the lock is released. Every subscriber has a bounded
+// backlog delivered by its own goroutine, so that slow subscribers never block
+// the pool itself: one falling too far behind is unsubscribed with
+// ErrTxEventsOverflow.
+//
+// The zero value is ready to use.
+type TxEventFeed struct {
+	subs   map[*txEventSub]struct{}
+	queue  []TxEvent
+	closed bool
+	lock   sync.Mutex // Protects the subscribers and the queued events
+}
+
+// txEventSub is the backlog of a single subscriber.
+type txEventSub struct {
+	backlog  chan []TxEvent
+	overflow chan struct{} // Closed when the backlog overflows
+	closed   chan struct{} // Closed when the feed is closed
+}
+
+// Subscribe registers a subscription for the events of the subpool.
+func (f *TxEventFeed) Subscribe(ch chan<- []TxEvent) event.Subscription {
+	sub := &txEventSub{
+		backlog:  make(chan []TxEvent, maxTxEventBacklog),
+		overflow: make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+	f.lock.Lock()
+	if f.closed {
+		close(sub.closed)
+	} else {
+		if f.subs == nil {
+			f.subs = make(map[*txEventSub]struct{})
+		}
+		f.subs[sub] = struct{}{}
+	}
+	f.lock.Unlock()
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer f.remove(sub)
+		for {
+			select {
+			case events := <-sub.backlog:
+				select {
+				case ch <- events:
+				case <-sub.overflow:
+					return ErrTxEventsOverflow
+				case <-quit:
+					return nil
+				case <-sub.closed:
+					return nil
+				}
+			case <-sub.overflow:
+				return ErrTxEventsOverflow
+			case <-quit:
+				return nil
+			case <-sub.closed:
+				return nil
+			}
+		}
+	})
+}
+
+// remove unregisters a subscriber.
+func (f *TxEventFeed) remove(sub *txEventSub) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.subs, sub)
+}
+
+// Active returns whether anyone is subscribed to the events. Events are only
+// worth assembling if so.
+func (f *TxEventFeed) Active() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return len(f.subs) > 0
+}
+
+// Queue schedules the given events for sending out on the next flush.
+func (f *TxEventFeed) Queue(events ...TxEvent) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if len(f.subs) > 0 {
+		f.queue = append(f.queue, events...)
+	}
+}
+
+// Flush hands all the queued events over to the backlogs of the subscribers,
+// without waiting for them to be received.
+func (f *TxEventFeed) Flush() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	events := f.queue
+	f.queue = nil
+	if len(events) == 0 {
+		return
+	}
+	for sub := range f.subs {
+		select {
+		case sub.backlog <- events:
+		default:
+			// The backlog is full, drop the subscriber
+			close(sub.overflow)
+			delete(f.subs, sub)
+		}
+	}
+}
+
+// Close unsubscribes all the subscribers.
+func (f *TxEventFeed) Close() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for sub := range f.subs {
+		close(sub.closed)
+	}
+	f.subs, f.queue, f.closed = nil, nil, true
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that a subscriber never reading its events does not block the flushes,
+// and is dropped with an overflow error, while the others receive every event
+// in order.
+func TestTxEventFeedSlowSubscriber(t *testing.T) {
+	var feed TxEventFeed
+	defer feed.Close()
+
+	stalled := make(chan []TxEvent)
+	stalledSub := feed.Subscribe(stalled)
+	defer stalledSub.Unsubscribe()
+
+	events := make(chan []TxEvent)
+	sub := feed.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	// Flush more batches than the backlog holds, the stalled subscriber must
+	// not hold up the flushes nor the deliveries to the other one
+	for nonce := uint64(0); nonce < 2*maxTxEventBacklog; nonce++ {
+		feed.Queue(TxEvent{Type: TxEventAdded, Tx: types.NewTx(&types.LegacyTx{Nonce: nonce})})
+		feed.Flush()
+
+		select {
+		case evs := <-events:
+			if len(evs) != 1 || evs[0].Tx.Nonce() != nonce {
+				t.Fatalf("batch %d: wrong events %v", nonce, evs)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("batch %d not received", nonce)
+		}
+	}
+	select {
+	case err := <-stalledSub.Err():
+		if err != ErrTxEventsOverflow {
+			t.Fatalf("wrong error of the stalled subscription: have %v, want %v", err, ErrTxEventsOverflow)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stalled subscriber not dropped")
+	}
+	if !feed.Active() {
+		t.Fatal("reading subscriber dropped")
+	}
+}
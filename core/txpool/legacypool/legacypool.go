@@ -204,6 +204,7 @@ type LegacyPool struct {
 	chain       BlockChain
 	gasTip      atomic.Pointer[big.Int]
 	txFeed      event.Feed
+	eventFeed   txpool.TxEventFeed
 	signer      types.Signer
 	mu          sync.RWMutex
 
@@ -229,7 +230,8 @@ type LegacyPool struct {
 	wg              sync.WaitGroup // tracks loop, scheduleReorgLoop
 	initDoneCh      chan struct{}  // is closed once the pool is initialized (for tests)
 
-	changesSinceReorg int // A counter for how many drops we've performed in-between reorg.
+	changesSinceReorg int                      // A counter for how many drops we've performed in-between reorg.
+	included          map[common.Hash]struct{} // Transactions included by the blocks of an ongoing reset
 }
 
 type txpoolResetRequest struct {
@@ -376,6 +378,7 @@ func (pool *LegacyPool) loop() {
 				// Any non-locals old enough should be removed
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					list := pool.queue[addr].Flatten()
+					pool.queueDropEvents(txpool.TxDropEvicted, list)
 					for _, tx := range list {
 						pool.removeTx(tx.Hash(), true, true)
 					}
@@ -383,6 +386,7 @@ func (pool *LegacyPool) loop() {
 				}
 			}
 			pool.mu.Unlock()
+			pool.eventFeed.Flush()
 
 		// Handle local transaction journal rotation
 		case <-journal.C:
@@ -403,6 +407,9 @@ func (pool *LegacyPool) Close() error {
 	close(pool.reorgShutdownCh)
 	pool.wg.Wait()
 
+	// Unsubscribe anyone still listening for tx events
+	pool.eventFeed.Close()
+
 	if pool.journal != nil {
 		pool.journal.close()
 	}
@@ -427,9 +434,17 @@ func (pool *LegacyPool) SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs
 	return pool.txFeed.Subscribe(ch)
 }
 
+// SubscribeEvents registers a subscription for the transitions of the pooled
+// transactions.
+func (pool *LegacyPool) SubscribeEvents(ch chan<- []txpool.TxEvent) event.Subscription {
+	return pool.eventFeed.Subscribe(ch)
+}
+
 // SetGasTip updates the minimum gas tip required by the transaction pool for a
 // new transaction, and drops all transactions below this threshold.
 func (pool *LegacyPool) SetGasTip(tip *big.Int) {
+	defer pool.eventFeed.Flush()
+
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
@@ -440,6 +455,7 @@ func (pool *LegacyPool) SetGasTip(tip *big.Int) {
 	if tip.Cmp(old) > 0 {
 		// pool.priced is sorted by GasFeeCap, so we have to iterate through pool.all instead
 		drop := pool.all.RemotesBelowTip(tip)
+		pool.queueDropEvents(txpool.TxDropUnderpriced, drop)
 		for _, tx := range drop {
 			pool.removeTx(tx.Hash(), false, true)
 		}
@@ -747,6 +763,7 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 		for _, tx := range drop {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "gasTipCap", tx.GasTipCap(), "gasFeeCap", tx.GasFeeCap())
 			underpricedTxMeter.Mark(1)
+			pool.queueEvent(txpool.TxEvent{Type: txpool.TxEventDropped, Tx: tx, Reason: txpool.TxDropUnderpriced})
 
 			sender, _ := types.Sender(pool.signer, tx)
 			dropped := pool.removeTx(tx.Hash(), false, sender != from) // Don't unreserve the sender of the tx being added if last from the acc
@@ -768,9 +785,11 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
 			pendingReplaceMeter.Mark(1)
+			pool.queueEvent(txpool.TxEvent{Type: txpool.TxEventReplaced, Tx: old, ReplacedBy: hash})
 		}
 		pool.all.Add(tx, isLocal)
 		pool.priced.Put(tx, isLocal)
+		pool.queueEvent(txpool.TxEvent{Type: txpool.TxEventAdded, Tx: tx, Pending: true})
 		pool.journalTx(from, tx)
 		pool.queueTxEvent(tx)
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
@@ -843,6 +862,7 @@ func (pool *LegacyPool) enqueueTx(hash common.Hash, tx *types.Transaction, local
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		queuedReplaceMeter.Mark(1)
+		pool.queueEvent(txpool.TxEvent{Type: txpool.TxEventReplaced, Tx: old, ReplacedBy: hash})
 	} else {
 		// Nothing was replaced, bump the queued counter
 		queuedGauge.Inc(1)
@@ -855,6 +875,7 @@ func (pool *LegacyPool) enqueueTx(hash common.Hash, tx *types.Transaction, local
 	if addAll {
 		pool.all.Add(tx, local)
 		pool.priced.Put(tx, local)
+		pool.queueEvent(txpool.TxEvent{Type: txpool.TxEventAdded, Tx: tx})
 	}
 	// If we never record the heartbeat, do it right now.
 	if _, exist := pool.beats[from]; !exist {
@@ -875,6 +896,42 @@ func (pool *LegacyPool) journalTx(from common.Address, tx *types.Transaction) {
 	}
 }
 
+// queueEvent schedules a transaction event for the subscribers, filling in the
+// sender of the transaction.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *LegacyPool) queueEvent(ev txpool.TxEvent) {
+	if !pool.eventFeed.Active() {
+		return
+	}
+	ev.From, _ = types.Sender(pool.signer, ev.Tx) // already validated during insertion
+	pool.eventFeed.Queue(ev)
+}
+
+// queueDropEvents schedules a dropped event for each of the given transactions.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *LegacyPool) queueDropEvents(reason txpool.TxDropReason, txs []*types.Transaction) {
+	for _, tx := range txs {
+		pool.queueEvent(txpool.TxEvent{Type: txpool.TxEventDropped, Tx: tx, Reason: reason})
+	}
+}
+
+// queueStaleEvents schedules an event for each of the given transactions whose
+// nonce was used up by the chain: an included event if the transaction itself
+// made it into a block, a dropped one otherwise.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *LegacyPool) queueStaleEvents(txs []*types.Transaction) {
+	for _, tx := range txs {
+		if _, ok := pool.included[tx.Hash()]; ok {
+			pool.queueEvent(txpool.TxEvent{Type: txpool.TxEventIncluded, Tx: tx})
+		} else {
+			pool.queueEvent(txpool.TxEvent{Type: txpool.TxEventDropped, Tx: tx, Reason: txpool.TxDropNonceTooLow})
+		}
+	}
+}
+
 // promoteTx adds a transaction to the pending (processable) list of transactions
 // and returns whether it was inserted or an older was better.
 //
@@ -892,13 +949,17 @@ func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *typ
 		pool.all.Remove(hash)
 		pool.priced.Removed(1)
 		pendingDiscardMeter.Mark(1)
+		pool.queueEvent(txpool.TxEvent{Type: txpool.TxEventDropped, Tx: tx, Reason: txpool.TxDropUnderpriced})
 		return false
 	}
+	pool.queueEvent(txpool.TxEvent{Type: txpool.TxEventPromoted, Tx: tx})
+
 	// Otherwise discard any previous transaction and mark this
 	if old != nil {
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		pendingReplaceMeter.Mark(1)
+		pool.queueEvent(txpool.TxEvent{Type: txpool.TxEventReplaced, Tx: old, ReplacedBy: hash})
 	} else {
 		// Nothing was replaced, bump the pending counter
 		pendingGauge.Inc(1)
@@ -990,6 +1051,7 @@ func (pool *LegacyPool) Add(txs []*types.Transaction, local, sync bool) []error
 	pool.mu.Lock()
 	newErrs, dirtyAddrs := pool.addTxsLocked(news, local)
 	pool.mu.Unlock()
+	pool.eventFeed.Flush()
 
 	var nilSlot = 0
 	for _, err := range newErrs {
@@ -1112,7 +1174,9 @@ func (pool *LegacyPool) removeTx(hash common.Hash, outofbound bool, unreserve bo
 			// Postpone any invalidated transactions
 			for _, tx := range invalids {
 				// Internal shuffle shouldn't touch the lookup set.
-				pool.enqueueTx(tx.Hash(), tx, false, false)
+				if _, err := pool.enqueueTx(tx.Hash(), tx, false, false); err == nil {
+					pool.queueEvent(txpool.TxEvent{Type: txpool.TxEventDemoted, Tx: tx})
+				}
 			}
 			// Update the account nonce if needed
 			pool.pendingNonces.setIfLower(addr, tx.Nonce())
@@ -1251,7 +1315,14 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 		// the flatten operation can be avoided.
 		promoteAddrs = dirtyAccounts.flatten()
 	}
+	// Gather the transactions included by the new blocks, to tell them apart
+	// from the ones dropped for their nonce being used up by others
+	var included map[common.Hash]struct{}
+	if reset != nil && pool.eventFeed.Active() {
+		included = pool.inclusions(reset.oldHead, reset.newHead)
+	}
 	pool.mu.Lock()
+	pool.included = included
 	if reset != nil {
 		// Reset from the old head to the new, rescheduling any reorged transactions
 		pool.reset(reset.oldHead, reset.newHead)
@@ -1299,7 +1370,9 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 
 	dropBetweenReorgHistogram.Update(int64(pool.changesSinceReorg))
 	pool.changesSinceReorg = 0 // Reset change counter
+	pool.included = nil
 	pool.mu.Unlock()
+	pool.eventFeed.Flush()
 
 	// Notify subsystems for newly added transactions
 	for _, tx := range promoted {
@@ -1318,6 +1391,27 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 	}
 }
 
+// inclusions returns the hashes of the transactions included by the blocks from
+// the new head back to the old one, or to the reorg depth limit if the old head
+// is not an ancestor.
+func (pool *LegacyPool) inclusions(oldHead, newHead *types.Header) map[common.Hash]struct{} {
+	included := make(map[common.Hash]struct{})
+	if oldHead == nil || newHead == nil {
+		return included
+	}
+	block := pool.chain.GetBlock(newHead.Hash(), newHead.Number.Uint64())
+	for depth := 0; block != nil && depth < 64 && block.Hash() != oldHead.Hash(); depth++ {
+		for _, tx := range block.Transactions() {
+			included[tx.Hash()] = struct{}{}
+		}
+		if block.NumberU64() == 0 {
+			break
+		}
+		block = pool.chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	}
+	return included
+}
+
 // reset retrieves the current state of the blockchain and ensures the content
 // of the transaction pool is valid with regard to the chain state.
 func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
@@ -1433,6 +1527,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 		}
 		// Drop all transactions that are deemed too old (low nonce)
 		forwards := list.Forward(pool.currentState.GetNonce(addr))
+		pool.queueStaleEvents(forwards)
 		for _, tx := range forwards {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
@@ -1440,6 +1535,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 		log.Trace("Removed old queued transactions", "count", len(forwards))
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), gasLimit)
+		pool.queueDropEvents(txpool.TxDropUnpayable, drops)
 		for _, tx := range drops {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
@@ -1462,6 +1558,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 		var caps types.Transactions
 		if !pool.locals.contains(addr) {
 			caps = list.Cap(int(pool.config.AccountQueue))
+			pool.queueDropEvents(txpool.TxDropOverflow, caps)
 			for _, tx := range caps {
 				hash := tx.Hash()
 				pool.all.Remove(hash)
@@ -1526,6 +1623,7 @@ func (pool *LegacyPool) truncatePending() {
 					list := pool.pending[offenders[i]]
 
 					caps := list.Cap(list.Len() - 1)
+					pool.queueDropEvents(txpool.TxDropOverflow, caps)
 					for _, tx := range caps {
 						// Drop the transaction from the global pools too
 						hash := tx.Hash()
@@ -1553,6 +1651,7 @@ func (pool *LegacyPool) truncatePending() {
 				list := pool.pending[addr]
 
 				caps := list.Cap(list.Len() - 1)
+				pool.queueDropEvents(txpool.TxDropOverflow, caps)
 				for _, tx := range caps {
 					// Drop the transaction from the global pools too
 					hash := tx.Hash()
@@ -1602,7 +1701,9 @@ func (pool *LegacyPool) truncateQueue() {
 
 		// Drop all transactions if they are less than the overflow
 		if size := uint64(list.Len()); size <= drop {
-			for _, tx := range list.Flatten() {
+			txs := list.Flatten()
+			pool.queueDropEvents(txpool.TxDropOverflow, txs)
+			for _, tx := range txs {
 				pool.removeTx(tx.Hash(), true, true)
 			}
 			drop -= size
@@ -1612,6 +1713,7 @@ func (pool *LegacyPool) truncateQueue() {
 		// Otherwise drop only last few transactions
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
+			pool.queueEvent(txpool.TxEvent{Type: txpool.TxEventDropped, Tx: txs[i], Reason: txpool.TxDropOverflow})
 			pool.removeTx(txs[i].Hash(), true, true)
 			drop--
 			queuedRateLimitMeter.Mark(1)
@@ -1634,6 +1736,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 
 		// Drop all transactions that are deemed too old (low nonce)
 		olds := list.Forward(nonce)
+		pool.queueStaleEvents(olds)
 		for _, tx := range olds {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
@@ -1641,6 +1744,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), gasLimit)
+		pool.queueDropEvents(txpool.TxDropUnpayable, drops)
 		for _, tx := range drops {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
@@ -1653,7 +1757,9 @@ func (pool *LegacyPool) demoteUnexecutables() {
 			log.Trace("Demoting pending transaction", "hash", hash)
 
 			// Internal shuffle shouldn't touch the lookup set.
-			pool.enqueueTx(hash, tx, false, false)
+			if _, err := pool.enqueueTx(hash, tx, false, false); err == nil {
+				pool.queueEvent(txpool.TxEvent{Type: txpool.TxEventDemoted, Tx: tx})
+			}
 		}
 		pendingGauge.Dec(int64(len(olds) + len(drops) + len(invalids)))
 		if pool.locals.contains(addr) {
@@ -1667,7 +1773,9 @@ func (pool *LegacyPool) demoteUnexecutables() {
 				log.Error("Demoting invalidated transaction", "hash", hash)
 
 				// Internal shuffle shouldn't touch the lookup set.
-				pool.enqueueTx(hash, tx, false, false)
+				if _, err := pool.enqueueTx(hash, tx, false, false); err == nil {
+					pool.queueEvent(txpool.TxEvent{Type: txpool.TxEventDemoted, Tx: tx})
+				}
 			}
 			pendingGauge.Dec(int64(len(gapped)))
 		}
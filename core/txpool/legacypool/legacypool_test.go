@@ -2299,6 +2299,127 @@ func TestReplacementDynamicFee(t *testing.T) {
 	}
 }
 
+// Tests that the transitions of the pooled transactions are announced on the
+// event feed, in the order they happen.
+func TestTxEvents(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Close()
+
+	events := make(chan []txpool.TxEvent, 32)
+	sub := pool.SubscribeEvents(events)
+	defer sub.Unsubscribe()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, from, big.NewInt(1000000000))
+
+	// Add a gapped transaction, and then the one filling the gap
+	tx0, tx1 := pricedTransaction(0, 100000, big.NewInt(1), key), pricedTransaction(1, 100000, big.NewInt(1), key)
+	if err := pool.addRemoteSync(tx1); err != nil {
+		t.Fatalf("failed to add queued transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(tx0); err != nil {
+		t.Fatalf("failed to add gap filling transaction: %v", err)
+	}
+	if err := validateTxEvents(events, from, []txpool.TxEvent{
+		{Type: txpool.TxEventAdded, Tx: tx1},
+		{Type: txpool.TxEventAdded, Tx: tx0},
+		{Type: txpool.TxEventPromoted, Tx: tx0},
+		{Type: txpool.TxEventPromoted, Tx: tx1},
+	}); err != nil {
+		t.Fatalf("addition events mismatch: %v", err)
+	}
+	// Replace the first pending transaction
+	replacement := pricedTransaction(0, 100000, big.NewInt(100), key)
+	if err := pool.addRemoteSync(replacement); err != nil {
+		t.Fatalf("failed to replace pending transaction: %v", err)
+	}
+	if err := validateTxEvents(events, from, []txpool.TxEvent{
+		{Type: txpool.TxEventReplaced, Tx: tx0, ReplacedBy: replacement.Hash()},
+		{Type: txpool.TxEventAdded, Tx: replacement, Pending: true},
+	}); err != nil {
+		t.Fatalf("replacement events mismatch: %v", err)
+	}
+	// Raise the minimum tip to drop the cheap transaction
+	pool.SetGasTip(big.NewInt(2))
+	if err := validateTxEvents(events, from, []txpool.TxEvent{
+		{Type: txpool.TxEventDropped, Tx: tx1, Reason: txpool.TxDropUnderpriced},
+	}); err != nil {
+		t.Fatalf("underpriced events mismatch: %v", err)
+	}
+	// Use up the nonce of the remaining transaction
+	testSetNonce(pool, from, 1)
+	<-pool.requestReset(nil, nil)
+	if err := validateTxEvents(events, from, []txpool.TxEvent{
+		{Type: txpool.TxEventDropped, Tx: replacement, Reason: txpool.TxDropNonceTooLow},
+	}); err != nil {
+		t.Fatalf("stale events mismatch: %v", err)
+	}
+	// Make the first of two pending transactions unpayable, demoting the other
+	expensive, cheap := pricedTransaction(1, 100000, big.NewInt(10), key), pricedTransaction(2, 100000, big.NewInt(2), key)
+	if err := pool.addRemoteSync(expensive); err != nil {
+		t.Fatalf("failed to add expensive transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(cheap); err != nil {
+		t.Fatalf("failed to add cheap transaction: %v", err)
+	}
+	if err := validateTxEvents(events, from, []txpool.TxEvent{
+		{Type: txpool.TxEventAdded, Tx: expensive},
+		{Type: txpool.TxEventPromoted, Tx: expensive},
+		{Type: txpool.TxEventAdded, Tx: cheap},
+		{Type: txpool.TxEventPromoted, Tx: cheap},
+	}); err != nil {
+		t.Fatalf("addition events mismatch: %v", err)
+	}
+	testAddBalance(pool, from, big.NewInt(-999500000))
+	<-pool.requestReset(nil, nil)
+	if err := validateTxEvents(events, from, []txpool.TxEvent{
+		{Type: txpool.TxEventDropped, Tx: expensive, Reason: txpool.TxDropUnpayable},
+		{Type: txpool.TxEventDemoted, Tx: cheap},
+	}); err != nil {
+		t.Fatalf("demotion events mismatch: %v", err)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// validateTxEvents checks that exactly the given transaction events were fired
+// on the pool's event feed, in order.
+func validateTxEvents(events chan []txpool.TxEvent, from common.Address, want []txpool.TxEvent) error {
+	var received []txpool.TxEvent
+
+	for len(received) < len(want) {
+		select {
+		case evs := <-events:
+			received = append(received, evs...)
+		case <-time.After(time.Second):
+			return fmt.Errorf("event #%d not fired", len(received))
+		}
+	}
+	select {
+	case evs := <-events:
+		received = append(received, evs...)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if len(received) != len(want) {
+		return fmt.Errorf("event count mismatch: have %d, want %d", len(received), len(want))
+	}
+	for i, ev := range received {
+		if ev.Type != want[i].Type || ev.Tx.Hash() != want[i].Tx.Hash() {
+			return fmt.Errorf("event #%d mismatch: have %v of %x, want %v of %x", i, ev.Type, ev.Tx.Hash(), want[i].Type, want[i].Tx.Hash())
+		}
+		if ev.From != from {
+			return fmt.Errorf("event #%d sender mismatch: have %x, want %x", i, ev.From, from)
+		}
+		if ev.Pending != want[i].Pending || ev.ReplacedBy != want[i].ReplacedBy || ev.Reason != want[i].Reason {
+			return fmt.Errorf("event #%d details mismatch: have %+v, want %+v", i, ev, want[i])
+		}
+	}
+	return nil
+}
+
 // Tests that local transactions are journaled to disk, but remote transactions
 // get discarded between restarts.
 func TestJournaling(t *testing.T)         { testJournaling(t, false) }
@@ -115,6 +115,10 @@ type SubPool interface {
 	// or also for reorged out ones.
 	SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs bool) event.Subscription
 
+	// SubscribeEvents subscribes to the transitions of the pooled transactions,
+	// from their addition until their inclusion or removal.
+	SubscribeEvents(ch chan<- []TxEvent) event.Subscription
+
 	// Nonce returns the next nonce of an account, with all transactions executable
 	// by the pool already applied on top.
 	Nonce(addr common.Address) uint64
@@ -95,7 +95,13 @@ func New(gasTip *big.Int, chain BlockChain, subpools []SubPool) (*TxPool, error)
 			return nil, err
 		}
 	}
-	go pool.loop(head, chain)
+	// Subscribe to chain head events before returning, so that a head set
+	// right after the pool is created is not missed.
+	var (
+		newHeadCh  = make(chan core.ChainHeadEvent)
+		newHeadSub = chain.SubscribeChainHeadEvent(newHeadCh)
+	)
+	go pool.loop(head, newHeadCh, newHeadSub)
 	return pool, nil
 }
 
@@ -173,12 +179,8 @@ func (p *TxPool) Close() error {
 // loop is the transaction pool's main event loop, waiting for and reacting to
 // outside blockchain events as well as for various reporting and transaction
 // eviction events.
-func (p *TxPool) loop(head *types.Header, chain BlockChain) {
-	// Subscribe to chain head events to trigger subpool resets
-	var (
-		newHeadCh  = make(chan core.ChainHeadEvent)
-		newHeadSub = chain.SubscribeChainHeadEvent(newHeadCh)
-	)
+func (p *TxPool) loop(head *types.Header, newHeadCh <-chan core.ChainHeadEvent, newHeadSub event.Subscription) {
+	// Chain head events trigger subpool resets
 	defer newHeadSub.Unsubscribe()
 
 	// Track the previous and current head to feed to an idle reset
@@ -328,6 +330,16 @@ func (p *TxPool) SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs bool)
 	return p.subs.Track(event.JoinSubscriptions(subs...))
 }
 
+// SubscribeEvents registers a subscription for the transitions of the pooled
+// transactions across all subpools.
+func (p *TxPool) SubscribeEvents(ch chan<- []TxEvent) event.Subscription {
+	subs := make([]event.Subscription, len(p.subpools))
+	for i, subpool := range p.subpools {
+		subs[i] = subpool.SubscribeEvents(ch)
+	}
+	return p.subs.Track(event.JoinSubscriptions(subs...))
+}
+
 // Nonce returns the next nonce of an account, with all transactions executable
 // by the pool already applied on top.
 func (p *TxPool) Nonce(addr common.Address) uint64 {
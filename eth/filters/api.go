@@ -289,6 +289,163 @@ func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subsc
 	return rpcSub, nil
 }
 
+// LogsFrom creates a subscription that fires for all logs matching the given
+// filter criteria from the "fromBlock" of the criteria on. The logs of the blocks
+// already imported are replayed first, then the new logs follow, without gaps
+// or duplicates, so that a client can resume from the last block it processed.
+// Each log is sent with the finality status of its block. In case logs are
+// removed (chain reorg) previously sent logs are sent again with the removed
+// property set to true. If the replay fails, or too many new logs pile up
+// while it runs, a last notification carrying the error is sent and no more
+// logs follow.
+//
+// With "pending" as from or to block, the logs of the pending block are sent
+// too, with the pending status, each time the block is rebuilt once the replay
+// is over. They may never make it into the chain.
+func (api *FilterAPI) LogsFrom(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if crit.BlockHash != nil || (crit.ToBlock != nil && !isBlock(crit.ToBlock, rpc.LatestBlockNumber) && !isBlock(crit.ToBlock, rpc.PendingBlockNumber)) {
+		return nil, errInvalidBlockRange
+	}
+	var (
+		rpcSub      = notifier.CreateSubscription()
+		matchedLogs = make(chan []*types.Log)
+		pendingLogs chan []*types.Log
+		live        = ethereum.FilterQuery{Addresses: crit.Addresses, Topics: crit.Topics}
+	)
+	// Subscribe before reading the head, so that no block is missed in between.
+	logsSub, err := api.events.SubscribeLogs(live, matchedLogs)
+	if err != nil {
+		return nil, err
+	}
+	var pendingSub *Subscription
+	if isBlock(crit.FromBlock, rpc.PendingBlockNumber) || isBlock(crit.ToBlock, rpc.PendingBlockNumber) {
+		pending := big.NewInt(rpc.PendingBlockNumber.Int64())
+		pendingLogs = make(chan []*types.Log)
+		pendingSub, err = api.events.SubscribeLogs(ethereum.FilterQuery{FromBlock: pending, ToBlock: pending, Addresses: crit.Addresses, Topics: crit.Topics}, pendingLogs)
+		if err != nil {
+			logsSub.Unsubscribe()
+			return nil, err
+		}
+	}
+	head := api.sys.backend.CurrentHeader()
+	from, err := resolveFrom(ctx, api.sys.backend, crit.FromBlock, head)
+	if err != nil {
+		logsSub.Unsubscribe()
+		if pendingSub != nil {
+			pendingSub.Unsubscribe()
+		}
+		return nil, err
+	}
+	var (
+		cursor          = newLogCursor(api.sys.backend, from)
+		replay          = make(chan []*types.Log)
+		replayDone      = make(chan error, 1)
+		replayCtx, stop = context.WithCancel(context.Background())
+	)
+	go func() {
+		replayDone <- api.replayLogs(replayCtx, crit, from, head.Number.Uint64(), replay)
+	}()
+
+	go func() {
+		defer logsSub.Unsubscribe()
+		defer stop()
+		if pendingSub != nil {
+			defer pendingSub.Unsubscribe()
+		}
+
+		var queue [][]*types.Log // New logs received during the replay
+		notify := func(logs []*types.Log) {
+			if logs = cursor.filter(logs); len(logs) == 0 {
+				return
+			}
+			for i, status := range cursor.statuses(replayCtx, logs) {
+				notifier.Notify(rpcSub.ID, &StatusLog{Log: logs[i], Status: status})
+			}
+		}
+		for {
+			select {
+			case logs := <-replay:
+				notify(logs)
+			case err := <-replayDone:
+				if err != nil {
+					notifier.Notify(rpcSub.ID, &StatusLog{Error: fmt.Sprintf("failed to replay logs: %v", err)})
+					return
+				}
+				for _, logs := range queue {
+					notify(logs)
+				}
+				queue, replayDone = nil, nil
+				cursor.live = true
+			case logs := <-matchedLogs:
+				if cursor.live {
+					notify(logs)
+					continue
+				}
+				if len(queue) >= maxReplayQueue {
+					notifier.Notify(rpcSub.ID, &StatusLog{Error: "too many new logs during the replay"})
+					return
+				}
+				queue = append(queue, logs)
+			case logs := <-pendingLogs:
+				// The pending block is rebuilt all the time, the logs of the
+				// ones built during the replay are not worth keeping.
+				if !cursor.live {
+					continue
+				}
+				for _, log := range logs {
+					notifier.Notify(rpcSub.ID, &StatusLog{Log: log, Status: LogStatusPending})
+				}
+			case <-rpcSub.Err(): // client send an unsubscribe request
+				return
+			case <-notifier.Closed(): // connection dropped
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// replayLogs sends the logs matching the criteria of the blocks from..to, in
+// chunks. On failure, the replay stops short and the error is returned.
+func (api *FilterAPI) replayLogs(ctx context.Context, crit FilterCriteria, from, to uint64, replay chan<- []*types.Log) error {
+	for begin := from; begin <= to; begin += replayChunkSize {
+		end := begin + replayChunkSize - 1
+		if end > to {
+			end = to
+		}
+		f := api.sys.NewRangeFilter(int64(begin), int64(end), crit.Addresses, crit.Topics)
+		logs, err := f.Logs(ctx)
+		if err != nil {
+			return fmt.Errorf("blocks %d..%d: %w", begin, end, err)
+		}
+		// Split the logs per block, as the live logs are delivered, so that
+		// the cursor sees whole blocks
+		for len(logs) > 0 {
+			n := 1
+			for n < len(logs) && logs[n].BlockHash == logs[0].BlockHash {
+				n++
+			}
+			select {
+			case replay <- logs[:n]:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			logs = logs[n:]
+		}
+	}
+	return nil
+}
+
+// isBlock reports whether the block number of the criteria is the given one.
+func isBlock(number *big.Int, want rpc.BlockNumber) bool {
+	return number != nil && number.Int64() == want.Int64()
+}
+
 // FilterCriteria represents a request to create a new filter.
 // Same as ethereum.FilterQuery but with UnmarshalJSON() method.
 type FilterCriteria ethereum.FilterQuery
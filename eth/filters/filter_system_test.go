@@ -29,6 +29,7 @@ import (
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
@@ -40,6 +41,7 @@ import (
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 type testBackend struct {
@@ -975,3 +977,261 @@ func flattenLogs(pl [][]*types.Log) []*types.Log {
 	}
 	return logs
 }
+
+// TestLogsFrom tests that the logsFrom subscription replays the logs from the
+// requested block, then switches to the new logs without duplicates, and that
+// removed logs are only sent for blocks already delivered.
+func TestLogsFrom(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys, false)
+
+		addr  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		other = common.HexToAddress("0x2222222222222222222222222222222222222222")
+		gspec = &core.Genesis{
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Config:  params.TestChainConfig,
+		}
+	)
+	_, chain, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 5, func(i int, gen *core.BlockGen) {
+		gen.AddUncheckedReceipt(makeReceipt(addr))
+		gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.HexToAddress("0x999"), big.NewInt(999), 999, gen.BaseFee(), nil))
+	})
+	gspec.MustCommit(db, trie.NewDatabase(db, trie.HashDefaults))
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	rawdb.WriteFinalizedBlockHash(db, chain[1].Hash())
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	// Decode the fields of interest only, the logs of the test lack some
+	type statusLog struct {
+		Log struct {
+			BlockNumber hexutil.Uint64 `json:"blockNumber"`
+			BlockHash   common.Hash    `json:"blockHash"`
+			Removed     bool           `json:"removed"`
+		} `json:"log"`
+		Status string `json:"status"`
+	}
+	logs := make(chan statusLog)
+	sub, err := client.EthSubscribe(context.Background(), logs, "logsFrom", map[string]interface{}{"fromBlock": "0x2", "address": addr})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	type wantLog struct {
+		number  uint64
+		hash    common.Hash
+		removed bool
+		status  string
+	}
+	expect := func(want ...wantLog) {
+		t.Helper()
+		for i, w := range want {
+			select {
+			case have := <-logs:
+				if uint64(have.Log.BlockNumber) != w.number || have.Log.BlockHash != w.hash || have.Log.Removed != w.removed || have.Status != w.status {
+					t.Fatalf("log %d: have block %d %x removed %v status %s, want block %d %x removed %v status %s", i,
+						have.Log.BlockNumber, have.Log.BlockHash, have.Log.Removed, have.Status, w.number, w.hash, w.removed, w.status)
+				}
+			case err := <-sub.Err():
+				t.Fatalf("subscription failed: %v", err)
+			case <-time.After(time.Second):
+				t.Fatalf("log %d not received", i)
+			}
+		}
+	}
+	var (
+		head    = chain[4]
+		next    = common.Hash{0x06}
+		reorged = common.Hash{0x07}
+	)
+	// The logs of the head may be posted after the subscription, racing with
+	// the replay. Logs of other addresses must be filtered out.
+	backend.logsFeed.Send([]*types.Log{{Address: addr, BlockNumber: head.NumberU64(), BlockHash: head.Hash()}})
+	backend.logsFeed.Send([]*types.Log{{Address: addr, BlockNumber: 6, BlockHash: next}, {Address: other, BlockNumber: 6, BlockHash: next}})
+	expect(
+		wantLog{2, chain[1].Hash(), false, LogStatusFinalized},
+		wantLog{3, chain[2].Hash(), false, LogStatusLatest},
+		wantLog{4, chain[3].Hash(), false, LogStatusLatest},
+		wantLog{5, chain[4].Hash(), false, LogStatusLatest},
+		wantLog{6, next, false, LogStatusLatest},
+	)
+	// Removed logs of a block never delivered must not be sent
+	backend.rmLogsFeed.Send(core.RemovedLogsEvent{Logs: []*types.Log{{Address: addr, BlockNumber: 6, BlockHash: common.Hash{0xff}, Removed: true}}})
+	backend.rmLogsFeed.Send(core.RemovedLogsEvent{Logs: []*types.Log{{Address: addr, BlockNumber: 6, BlockHash: next, Removed: true}}})
+	expect(wantLog{6, next, true, LogStatusLatest})
+
+	backend.logsFeed.Send([]*types.Log{{Address: addr, BlockNumber: 6, BlockHash: reorged}})
+	expect(wantLog{6, reorged, false, LogStatusLatest})
+
+	select {
+	case have := <-logs:
+		t.Fatalf("unexpected log: %+v", have.Log)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestLogsFromReplayFailure tests that the logsFrom subscription reports a
+// failed replay to the subscriber and sends no more logs after it, the new
+// ones included.
+func TestLogsFromReplayFailure(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys, false)
+
+		addr  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		gspec = &core.Genesis{
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Config:  params.TestChainConfig,
+		}
+	)
+	_, chain, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 4, func(i int, gen *core.BlockGen) {
+		gen.AddUncheckedReceipt(makeReceipt(addr))
+		gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.HexToAddress("0x999"), big.NewInt(999), 999, gen.BaseFee(), nil))
+	})
+	gspec.MustCommit(db, trie.NewDatabase(db, trie.HashDefaults))
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	// The logs of the third block cannot be completed without its body
+	rawdb.DeleteBody(db, chain[2].Hash(), chain[2].NumberU64())
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	type statusLog struct {
+		Log *struct {
+			BlockNumber hexutil.Uint64 `json:"blockNumber"`
+		} `json:"log"`
+		Error string `json:"error"`
+	}
+	logs := make(chan statusLog)
+	sub, err := client.EthSubscribe(context.Background(), logs, "logsFrom", map[string]interface{}{"fromBlock": "0x1", "address": addr})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	// The new logs are queued behind the replay, then dropped with it
+	backend.logsFeed.Send([]*types.Log{{Address: addr, BlockNumber: 5, BlockHash: common.Hash{0x05}}})
+
+	select {
+	case have := <-logs:
+		if have.Log != nil || have.Error == "" {
+			t.Fatalf("wrong notification: have %+v, want replay error", have)
+		}
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-time.After(time.Second):
+		t.Fatalf("replay error not received")
+	}
+	select {
+	case have := <-logs:
+		t.Fatalf("unexpected notification after the failure: %+v", have)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestLogsFromPending tests that the logsFrom subscription delivers the logs of
+// the pending block with the pending status once the replay is over.
+func TestLogsFromPending(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys, false)
+
+		addr  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		other = common.HexToAddress("0x2222222222222222222222222222222222222222")
+		gspec = &core.Genesis{
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Config:  params.TestChainConfig,
+		}
+	)
+	_, chain, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2, func(i int, gen *core.BlockGen) {
+		gen.AddUncheckedReceipt(makeReceipt(addr))
+		gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.HexToAddress("0x999"), big.NewInt(999), 999, gen.BaseFee(), nil))
+	})
+	gspec.MustCommit(db, trie.NewDatabase(db, trie.HashDefaults))
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	type statusLog struct {
+		Log struct {
+			BlockNumber hexutil.Uint64 `json:"blockNumber"`
+		} `json:"log"`
+		Status string `json:"status"`
+	}
+	logs := make(chan statusLog)
+	sub, err := client.EthSubscribe(context.Background(), logs, "logsFrom", map[string]interface{}{"fromBlock": "0x1", "toBlock": "pending", "address": addr})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	expect := func(number uint64, status string) {
+		t.Helper()
+		select {
+		case have := <-logs:
+			if uint64(have.Log.BlockNumber) != number || have.Status != status {
+				t.Fatalf("have log of block %d status %s, want block %d status %s", have.Log.BlockNumber, have.Status, number, status)
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(time.Second):
+			t.Fatalf("log of block %d not received", number)
+		}
+	}
+	// A new log is only delivered once the replay is over
+	backend.logsFeed.Send([]*types.Log{{Address: addr, BlockNumber: 3, BlockHash: common.Hash{0x03}}})
+	expect(1, LogStatusLatest)
+	expect(2, LogStatusLatest)
+	expect(3, LogStatusLatest)
+
+	backend.pendingLogsFeed.Send([]*types.Log{{Address: other, BlockNumber: 4}, {Address: addr, BlockNumber: 4}})
+	expect(4, LogStatusPending)
+
+	select {
+	case have := <-logs:
+		t.Fatalf("unexpected log: %+v", have)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
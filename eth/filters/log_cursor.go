@@ -0,0 +1,161 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// replayChunkSize is the number of blocks whose logs are replayed at once.
+	replayChunkSize = 2048
+
+	// maxReplayQueue is the number of new log batches, one per event, kept
+	// while the replay runs before the subscription is given up.
+	maxReplayQueue = 1024
+)
+
+// Finality statuses of the logs delivered by the logsFrom subscription.
+const (
+	LogStatusLatest    = "latest"
+	LogStatusSafe      = "safe"
+	LogStatusFinalized = "finalized"
+	LogStatusPending   = "pending" // Log of the pending block, not in the chain yet
+)
+
+// StatusLog is a log delivered by the logsFrom subscription, with the finality
+// of its block at the time of delivery.
+type StatusLog struct {
+	Log    *types.Log `json:"log,omitempty"`
+	Status string     `json:"status,omitempty"`
+	Error  string     `json:"error,omitempty"` // Set on the last notification of a failed subscription
+}
+
+// logCursor tracks the blocks whose logs were delivered to a subscriber, so
+// that the replayed and the live logs are delivered exactly once, and removed
+// logs only for the blocks the subscriber has seen.
+type logCursor struct {
+	backend Backend
+	from    uint64                 // First block of the subscription
+	seen    map[common.Hash]uint64 // Delivered blocks, above the finalized one once live
+	live    bool                   // Whether the replay is over and the queued logs delivered
+}
+
+func newLogCursor(backend Backend, from uint64) *logCursor {
+	return &logCursor{
+		backend: backend,
+		from:    from,
+		seen:    make(map[common.Hash]uint64),
+	}
+}
+
+// resolveFrom returns the first block to deliver the logs of. Without a block,
+// or with the latest or the pending one, only the logs of the blocks after the
+// head are delivered.
+func resolveFrom(ctx context.Context, backend Backend, from *big.Int, head *types.Header) (uint64, error) {
+	if from == nil {
+		return head.Number.Uint64() + 1, nil
+	}
+	switch number := rpc.BlockNumber(from.Int64()); number {
+	case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+		return head.Number.Uint64() + 1, nil
+	case rpc.SafeBlockNumber, rpc.FinalizedBlockNumber:
+		header, err := backend.HeaderByNumber(ctx, number)
+		if err != nil {
+			return 0, err
+		}
+		if header == nil {
+			return 0, errors.New("unknown block")
+		}
+		return header.Number.Uint64(), nil
+	default:
+		if number < 0 {
+			return 0, errInvalidBlockRange
+		}
+		return uint64(number), nil
+	}
+}
+
+// filter returns the logs of a batch to deliver, and records their blocks as
+// seen. A batch holds the logs of one or more whole blocks, either replayed,
+// new, or removed by a reorg.
+func (c *logCursor) filter(logs []*types.Log) []*types.Log {
+	var (
+		deliver = make(map[common.Hash]bool)
+		res     []*types.Log
+	)
+	for _, log := range logs {
+		ok, decided := deliver[log.BlockHash]
+		if !decided {
+			_, seen := c.seen[log.BlockHash]
+			// Removed logs are only of interest if the block was delivered,
+			// and new ones only if it was not.
+			ok = seen == log.Removed && (log.Removed || log.BlockNumber >= c.from)
+			deliver[log.BlockHash] = ok
+		}
+		if ok {
+			res = append(res, log)
+		}
+	}
+	for _, log := range res {
+		if log.Removed {
+			delete(c.seen, log.BlockHash)
+		} else {
+			c.seen[log.BlockHash] = log.BlockNumber
+		}
+	}
+	return res
+}
+
+// statuses returns the finality status of the given logs. Once live, the
+// blocks that can no longer be reorged are forgotten.
+func (c *logCursor) statuses(ctx context.Context, logs []*types.Log) []string {
+	var safe, finalized uint64
+	if header, _ := c.backend.HeaderByNumber(ctx, rpc.SafeBlockNumber); header != nil {
+		safe = header.Number.Uint64()
+	}
+	if header, _ := c.backend.HeaderByNumber(ctx, rpc.FinalizedBlockNumber); header != nil {
+		finalized = header.Number.Uint64()
+	}
+	res := make([]string, len(logs))
+	for i, log := range logs {
+		switch {
+		case log.Removed:
+			res[i] = LogStatusLatest
+		case finalized > 0 && log.BlockNumber <= finalized:
+			res[i] = LogStatusFinalized
+		case safe > 0 && log.BlockNumber <= safe:
+			res[i] = LogStatusSafe
+		default:
+			res[i] = LogStatusLatest
+		}
+	}
+	if c.live && finalized > 0 {
+		for hash, number := range c.seen {
+			if number <= finalized {
+				delete(c.seen, hash)
+			}
+		}
+	}
+	return res
+}
@@ -865,25 +865,7 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 // top of the provided block and returns them as a JSON object.
 func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (interface{}, error) {
 	// Try to retrieve the specified block
-	var (
-		err   error
-		block *types.Block
-	)
-	if hash, ok := blockNrOrHash.Hash(); ok {
-		block, err = api.blockByHash(ctx, hash)
-	} else if number, ok := blockNrOrHash.Number(); ok {
-		if number == rpc.PendingBlockNumber {
-			// We don't have access to the miner here. For tracing 'future' transactions,
-			// it can be done with block- and state-overrides instead, which offers
-			// more flexibility and stability than trying to trace on 'pending', since
-			// the contents of 'pending' is unstable and probably not a true representation
-			// of what the next actual block is likely to contain.
-			return nil, errors.New("tracing on top of pending is not supported")
-		}
-		block, err = api.blockByNumber(ctx, number)
-	} else {
-		return nil, errors.New("invalid arguments; neither block nor hash specified")
-	}
+	block, err := api.callBlock(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -919,6 +901,129 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 	return api.traceTx(ctx, msg, new(Context), vmctx, statedb, traceConfig)
 }
 
+// TraceBundle is a list of calls traced one after the other, in a block with
+// the given overrides on top of the ones of the trace config.
+type TraceBundle struct {
+	Transactions   []ethapi.TransactionArgs `json:"transactions"`
+	BlockOverrides *ethapi.BlockOverrides   `json:"blockOverride"`
+}
+
+// TraceStateContext selects the state the bundles of TraceCallMany execute on:
+// the state before the transaction of the block at the given index, or after
+// the block if the index is omitted or -1.
+type TraceStateContext struct {
+	BlockNumber      rpc.BlockNumberOrHash `json:"blockNumber"`
+	TransactionIndex *int                  `json:"transactionIndex"`
+}
+
+// TraceCallMany lets you trace a sequence of bundles of eth_calls executed one
+// after the other on the same state, each call seeing the changes of the
+// previous ones. It returns the traces of the calls, grouped by bundle.
+//
+// The timeout of the trace config bounds the whole sequence, not each call.
+func (api *API) TraceCallMany(ctx context.Context, bundles []TraceBundle, stateCtx TraceStateContext, config *TraceCallConfig) ([][]interface{}, error) {
+	block, err := api.callBlock(ctx, stateCtx.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	var (
+		vmctx   vm.BlockContext
+		statedb *state.StateDB
+		release StateReleaseFunc
+		index   = len(block.Transactions())
+	)
+	if stateCtx.TransactionIndex != nil && *stateCtx.TransactionIndex != -1 {
+		index = *stateCtx.TransactionIndex
+		if index < 0 || index > len(block.Transactions()) {
+			return nil, fmt.Errorf("transaction index %d out of range", index)
+		}
+	}
+	if index < len(block.Transactions()) {
+		_, vmctx, statedb, release, err = api.backend.StateAtTransaction(ctx, block, index, reexec)
+	} else {
+		statedb, release, err = api.backend.StateAtBlock(ctx, block, reexec, nil, true, false)
+		vmctx = core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var traceConfig *TraceConfig
+	if config != nil {
+		if err := config.StateOverrides.Apply(statedb); err != nil {
+			return nil, err
+		}
+		config.BlockOverrides.Apply(&vmctx)
+		traceConfig = &config.TraceConfig
+	}
+	// Every call is traced with the same deadline, so that the number of calls
+	// does not multiply the time the request may take.
+	timeout := defaultTraceTimeout
+	if traceConfig != nil && traceConfig.Timeout != nil {
+		if timeout, err = time.ParseDuration(*traceConfig.Timeout); err != nil {
+			return nil, err
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := make([][]interface{}, len(bundles))
+	for i, bundle := range bundles {
+		blockCtx := vmctx
+		bundle.BlockOverrides.Apply(&blockCtx)
+
+		results[i] = make([]interface{}, len(bundle.Transactions))
+		for j, args := range bundle.Transactions {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("bundle %d, call %d: %w", i, j, err)
+			}
+			msg, err := args.ToMessage(api.backend.RPCGasCap(), blockCtx.BaseFee)
+			if err != nil {
+				return nil, fmt.Errorf("bundle %d, call %d: %w", i, j, err)
+			}
+			res, err := api.traceTx(ctx, msg, new(Context), blockCtx, statedb, traceConfig)
+			if err != nil {
+				return nil, fmt.Errorf("bundle %d, call %d: %w", i, j, err)
+			}
+			results[i][j] = res
+			// Settle the call, so that the next one starts from a clean journal
+			// and access list.
+			statedb.Finalise(true)
+		}
+	}
+	return results, nil
+}
+
+// callBlock retrieves the block to execute calls on top of. The pending block
+// is not supported.
+func (api *API) callBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	var (
+		err   error
+		block *types.Block
+	)
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		block, err = api.blockByHash(ctx, hash)
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		if number == rpc.PendingBlockNumber {
+			// We don't have access to the miner here. For tracing 'future' transactions,
+			// it can be done with block- and state-overrides instead, which offers
+			// more flexibility and stability than trying to trace on 'pending', since
+			// the contents of 'pending' is unstable and probably not a true representation
+			// of what the next actual block is likely to contain.
+			return nil, errors.New("tracing on top of pending is not supported")
+		}
+		block, err = api.blockByNumber(ctx, number)
+	} else {
+		return nil, errors.New("invalid arguments; neither block nor hash specified")
+	}
+	return block, err
+}
+
 // traceTx configures a new tracer according to the provided configuration, and
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
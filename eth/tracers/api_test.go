@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"math/big"
 	"reflect"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -320,6 +321,135 @@ func TestTraceCall(t *testing.T) {
 	}
 }
 
+func TestTraceCallMany(t *testing.T) {
+	t.Parallel()
+
+	accounts := newAccounts(2)
+	counter := common.Address{0xc0}
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			accounts[1].addr: {Balance: big.NewInt(params.Ether)},
+			// Increments the counter in slot 0 and returns it
+			counter: {Code: common.Hex2Bytes("6000546001018060005560005260206000f3")},
+		},
+	}
+	genBlocks := 2
+	signer := types.HomesteadSigner{}
+	backend := newTestBackend(t, genBlocks, genesis, func(i int, b *core.BlockGen) {
+		// Transfer from account[0] to account[1]
+		//    value: 1000 wei
+		//    fee:   0 wei
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), accounts[1].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, accounts[0].key)
+		b.AddTx(tx)
+	})
+	defer backend.teardown()
+	api := NewAPI(backend)
+
+	// Returns the balance of account[1]
+	balance := hexutil.Bytes(append(append([]byte{0x73}, accounts[1].addr.Bytes()...), 0x31, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3))
+	var (
+		first = rpc.BlockNumber(1)
+		zero  = 0
+	)
+	var testSuite = []struct {
+		bundles  []TraceBundle
+		stateCtx TraceStateContext
+		want     [][]*big.Int
+	}{
+		// Calls see the changes of the previous calls, across bundles
+		{
+			bundles: []TraceBundle{
+				{Transactions: []ethapi.TransactionArgs{{From: &accounts[0].addr, To: &counter}, {From: &accounts[0].addr, To: &counter}}},
+				{Transactions: []ethapi.TransactionArgs{{From: &accounts[0].addr, To: &counter}}},
+				{
+					Transactions:   []ethapi.TransactionArgs{{From: &accounts[0].addr, Input: &hexutil.Bytes{0x43, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}}},
+					BlockOverrides: &ethapi.BlockOverrides{Number: (*hexutil.Big)(big.NewInt(0x1337))},
+				},
+			},
+			stateCtx: TraceStateContext{BlockNumber: rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)},
+			want:     [][]*big.Int{{big.NewInt(1), big.NewInt(2)}, {big.NewInt(3)}, {big.NewInt(0x1337)}},
+		},
+		// Before the first transaction of the block
+		{
+			bundles:  []TraceBundle{{Transactions: []ethapi.TransactionArgs{{From: &accounts[0].addr, Input: &balance}}}},
+			stateCtx: TraceStateContext{BlockNumber: rpc.BlockNumberOrHashWithNumber(first), TransactionIndex: &zero},
+			want:     [][]*big.Int{{big.NewInt(params.Ether)}},
+		},
+		// After the block
+		{
+			bundles:  []TraceBundle{{Transactions: []ethapi.TransactionArgs{{From: &accounts[0].addr, Input: &balance}}}},
+			stateCtx: TraceStateContext{BlockNumber: rpc.BlockNumberOrHashWithNumber(first)},
+			want:     [][]*big.Int{{big.NewInt(params.Ether + 1000)}},
+		},
+	}
+	for i, tc := range testSuite {
+		results, err := api.TraceCallMany(context.Background(), tc.bundles, tc.stateCtx, nil)
+		if err != nil {
+			t.Fatalf("test %d: failed to trace: %v", i, err)
+		}
+		if len(results) != len(tc.want) {
+			t.Fatalf("test %d: wrong number of bundles: have %d, want %d", i, len(results), len(tc.want))
+		}
+		for j, bundle := range results {
+			if len(bundle) != len(tc.want[j]) {
+				t.Fatalf("test %d, bundle %d: wrong number of traces: have %d, want %d", i, j, len(bundle), len(tc.want[j]))
+			}
+			for k, result := range bundle {
+				var have *logger.ExecutionResult
+				if err := json.Unmarshal(result.(json.RawMessage), &have); err != nil {
+					t.Fatalf("test %d, bundle %d, call %d: failed to unmarshal result %v", i, j, k, err)
+				}
+				if value := new(big.Int).SetBytes(common.FromHex(have.ReturnValue)); value.Cmp(tc.want[j][k]) != 0 {
+					t.Errorf("test %d, bundle %d, call %d: wrong return value: have %v, want %v", i, j, k, value, tc.want[j][k])
+				}
+			}
+		}
+	}
+	index := genBlocks + 1
+	if _, err := api.TraceCallMany(context.Background(), nil, TraceStateContext{BlockNumber: rpc.BlockNumberOrHashWithNumber(first), TransactionIndex: &index}, nil); err == nil {
+		t.Errorf("transaction index out of range: want error, have nothing")
+	}
+}
+
+// Tests that the timeout of TraceCallMany bounds the whole sequence of calls,
+// even though each call would complete within it.
+func TestTraceCallManyTimeout(t *testing.T) {
+	t.Parallel()
+
+	accounts := newAccounts(1)
+	loop := common.Address{0xc1}
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			// Jumps back to the start until it runs out of gas
+			loop: {Code: common.Hex2Bytes("5b600056")},
+		},
+	}
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {})
+	defer backend.teardown()
+	api := NewAPI(backend)
+
+	var (
+		gas     = hexutil.Uint64(100_000)
+		calls   = make([]ethapi.TransactionArgs, 4000)
+		timeout = "100ms"
+		config  = &TraceCallConfig{TraceConfig: TraceConfig{Config: &logger.Config{Limit: 1}, Timeout: &timeout}}
+	)
+	for i := range calls {
+		calls[i] = ethapi.TransactionArgs{From: &accounts[0].addr, To: &loop, Gas: &gas}
+	}
+	_, err := api.TraceCallMany(context.Background(), []TraceBundle{{Transactions: calls}}, TraceStateContext{BlockNumber: rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)}, config)
+	if err == nil {
+		t.Fatal("calls traced past the timeout")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) && !strings.Contains(err.Error(), "execution timeout") {
+		t.Fatalf("wrong error: %v", err)
+	}
+}
+
 func TestTraceTransaction(t *testing.T) {
 	t.Parallel()
 
@@ -656,16 +656,16 @@ type StorageResult struct {
 	Proof []string     `json:"proof"`
 }
 
-// proofList implements ethdb.KeyValueWriter and collects the proofs as
+// ProofList implements ethdb.KeyValueWriter and collects the proofs as
 // hex-strings for delivery to rpc-caller.
-type proofList []string
+type ProofList []string
 
-func (n *proofList) Put(key []byte, value []byte) error {
+func (n *ProofList) Put(key []byte, value []byte) error {
 	*n = append(*n, hexutil.Encode(value))
 	return nil
 }
 
-func (n *proofList) Delete(key []byte) error {
+func (n *ProofList) Delete(key []byte) error {
 	panic("not supported")
 }
 
@@ -717,7 +717,7 @@ func (s *BlockChainAPI) GetProof(ctx context.Context, address common.Address, st
 				storageProof[i] = StorageResult{outputKey, &hexutil.Big{}, []string{}}
 				continue
 			}
-			var proof proofList
+			var proof ProofList
 			if err := storageTrie.Prove(crypto.Keccak256(key.Bytes()), &proof); err != nil {
 				return nil, err
 			}
@@ -730,7 +730,7 @@ func (s *BlockChainAPI) GetProof(ctx context.Context, address common.Address, st
 	if err != nil {
 		return nil, err
 	}
-	var accountProof proofList
+	var accountProof ProofList
 	if err := tr.Prove(crypto.Keccak256(address.Bytes()), &accountProof); err != nil {
 		return nil, err
 	}
@@ -1062,6 +1062,16 @@ func doCall(ctx context.Context, b Backend, args TransactionArgs, state *state.S
 	if err := overrides.Apply(state); err != nil {
 		return nil, err
 	}
+	msg, err := args.ToMessage(globalGasCap, header.BaseFee)
+	if err != nil {
+		return nil, err
+	}
+	return applyMessage(ctx, b, msg, state, header, blockOverrides, timeout)
+}
+
+// applyMessage executes msg on top of state, in the context of the given block
+// with the overrides applied, aborting the execution after timeout if set.
+func applyMessage(ctx context.Context, b Backend, msg *core.Message, state *state.StateDB, header *types.Header, blockOverrides *BlockOverrides, timeout time.Duration) (*core.ExecutionResult, error) {
 	// Setup context so it may be cancelled the call has completed
 	// or, in case of unmetered gas, setup a context with a timeout.
 	var cancel context.CancelFunc
@@ -1075,10 +1085,6 @@ func doCall(ctx context.Context, b Backend, args TransactionArgs, state *state.S
 	defer cancel()
 
 	// Get a new instance of the EVM.
-	msg, err := args.ToMessage(globalGasCap, header.BaseFee)
-	if err != nil {
-		return nil, err
-	}
 	blockCtx := core.NewEVMBlockContext(header, NewChainContext(ctx, b), nil)
 	if blockOverrides != nil {
 		blockOverrides.Apply(&blockCtx)
@@ -1120,7 +1126,9 @@ func DoCall(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash
 	return doCall(ctx, b, args, state, header, overrides, blockOverrides, timeout, globalGasCap)
 }
 
-func newRevertError(result *core.ExecutionResult) *revertError {
+// NewRevertError creates an API error carrying the revert reason decoded
+// from the return data of the execution result.
+func NewRevertError(result *core.ExecutionResult) error {
 	reason, errUnpack := abi.UnpackRevert(result.Revert())
 	err := errors.New("execution reverted")
 	if errUnpack == nil {
@@ -1167,7 +1175,7 @@ func (s *BlockChainAPI) Call(ctx context.Context, args TransactionArgs, blockNrO
 	}
 	// If the result contains a revert reason, try to unpack and return it.
 	if len(result.Revert()) > 0 {
-		return nil, newRevertError(result)
+		return nil, NewRevertError(result)
 	}
 	return result.Return(), result.Err
 }
@@ -1273,7 +1281,7 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 	if failed {
 		if result != nil && !errors.Is(result.Err, vm.ErrOutOfGas) {
 			if len(result.Revert()) > 0 {
-				return 0, newRevertError(result)
+				return 0, NewRevertError(result)
 			}
 			return 0, result.Err
 		}
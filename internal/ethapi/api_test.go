@@ -26,6 +26,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -40,6 +41,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -579,6 +581,9 @@ func (b testBackend) TxPoolContentFrom(addr common.Address) ([]*types.Transactio
 func (b testBackend) SubscribeNewTxsEvent(events chan<- core.NewTxsEvent) event.Subscription {
 	panic("implement me")
 }
+func (b testBackend) SubscribeTxPoolEvents(events chan<- []txpool.TxEvent) event.Subscription {
+	panic("implement me")
+}
 func (b testBackend) ChainConfig() *params.ChainConfig { return b.chain.Config() }
 func (b testBackend) Engine() consensus.Engine         { return b.chain.Engine() }
 func (b testBackend) GetLogs(ctx context.Context, blockHash common.Hash, number uint64) ([][]*types.Log, error) {
@@ -911,6 +916,230 @@ func TestCall(t *testing.T) {
 	}
 }
 
+func TestCallMany(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(3)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				// Increments the counter in slot 0, logs and returns it
+				accounts[1].addr: {Balance: big.NewInt(params.Ether), Code: common.Hex2Bytes("6000546001018060005560005260206000a060206000f3")},
+			},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	api := NewBlockChainAPI(newTestBackend(t, 1, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {}))
+
+	transfer, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: 0, To: &accounts[2].addr, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)}), signer, accounts[0].key)
+	encoded, _ := transfer.MarshalBinary()
+
+	steps := []CallStep{
+		{Transaction: encoded},
+		{Call: &TransactionArgs{From: &accounts[0].addr, To: &accounts[1].addr}},
+		{Call: &TransactionArgs{From: &accounts[0].addr, To: &accounts[1].addr}},
+		// Replayed transaction, nonce too low
+		{Transaction: encoded},
+		{
+			Call:           &TransactionArgs{From: &accounts[0].addr, Input: &hexutil.Bytes{0x43, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}},
+			BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(11))},
+		},
+		// Reverts with 42
+		{Call: &TransactionArgs{From: &accounts[0].addr, To: &common.Address{0x2a}}},
+	}
+	overrides := StateOverride{
+		common.Address{0x2a}: OverrideAccount{Code: hex2Bytes("602a60005260206000fd")},
+	}
+	results, err := api.CallMany(context.Background(), steps, nil, &overrides)
+	if err != nil {
+		t.Fatalf("failed to call many: %v", err)
+	}
+	if len(results) != len(steps) {
+		t.Fatalf("wrong number of results: have %d, want %d", len(results), len(steps))
+	}
+	if res := results[0]; res.Error != "" || res.GasUsed != hexutil.Uint64(params.TxGas) {
+		t.Errorf("transaction failed: %+v", res)
+	}
+	for i, want := range []int64{1, 2} {
+		res := results[1+i]
+		if res.Error != "" {
+			t.Fatalf("call %d failed: %s", 1+i, res.Error)
+		}
+		if have := new(big.Int).SetBytes(res.ReturnData); have.Int64() != want {
+			t.Errorf("call %d: wrong counter: have %d, want %d", 1+i, have, want)
+		}
+		if len(res.Logs) != 1 || res.Logs[0].Address != accounts[1].addr || res.Logs[0].TxHash != (common.Hash{}) {
+			t.Errorf("call %d: wrong logs: %v", 1+i, res.Logs)
+		}
+	}
+	if res := results[3]; !strings.Contains(res.Error, core.ErrNonceTooLow.Error()) {
+		t.Errorf("replayed transaction: wrong error: %q", res.Error)
+	}
+	if have := new(big.Int).SetBytes(results[4].ReturnData); have.Int64() != 11 {
+		t.Errorf("block overrides not applied: have number %d, want 11", have)
+	}
+	if res := results[5]; res.Error != "execution reverted" || new(big.Int).SetBytes(res.RevertData).Int64() != 42 {
+		t.Errorf("wrong revert: %+v", res)
+	}
+}
+
+// Tests that a step failing before its execution starts leaves no trace in
+// the state seen by the following steps.
+func TestCallManyFailedStep(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(1)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	api := NewBlockChainAPI(newTestBackend(t, 1, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {}))
+
+	// Buys its gas, then fails the intrinsic gas check
+	underpriced, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: 0, To: &common.Address{0x01}, Gas: params.TxGas - 1, GasPrice: big.NewInt(params.InitialBaseFee)}), signer, accounts[0].key)
+	encoded, _ := underpriced.MarshalBinary()
+
+	// Returns the balance of the sender
+	code := append(append([]byte{0x73}, accounts[0].addr.Bytes()...), 0x31, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3)
+	steps := []CallStep{
+		{Transaction: encoded},
+		{Call: &TransactionArgs{To: &common.Address{0x2a}}},
+	}
+	overrides := StateOverride{
+		common.Address{0x2a}: OverrideAccount{Code: (*hexutil.Bytes)(&code)},
+	}
+	results, err := api.CallMany(context.Background(), steps, nil, &overrides)
+	if err != nil {
+		t.Fatalf("failed to call many: %v", err)
+	}
+	if res := results[0]; !strings.Contains(res.Error, core.ErrIntrinsicGas.Error()) {
+		t.Errorf("transaction with too little gas: wrong error: %q", res.Error)
+	}
+	if res := results[1]; res.Error != "" {
+		t.Fatalf("balance call failed: %s", res.Error)
+	}
+	if have, want := new(big.Int).SetBytes(results[1].ReturnData), big.NewInt(params.Ether); have.Cmp(want) != 0 {
+		t.Errorf("gas of the failed transaction not rolled back: have balance %d, want %d", have, want)
+	}
+}
+
+func TestSimulateV1(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(2)
+		counter  = common.Address{0xc0}
+		hasher   = common.Address{0xb1}
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				// Increments the counter in slot 0, logs and returns it
+				counter: {Code: common.Hex2Bytes("6000546001018060005560005260206000a060206000f3")},
+				// Returns the hash of the previous block
+				hasher: {Code: common.Hex2Bytes("600143034060005260206000f3")},
+			},
+		}
+		genBlocks = 2
+	)
+	backend := newTestBackend(t, genBlocks, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {})
+	api := NewBlockChainAPI(backend)
+	head, _ := backend.HeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+
+	calls := []TransactionArgs{
+		{From: &accounts[0].addr, To: &counter},
+		{From: &accounts[0].addr, To: &hasher},
+	}
+	number := (*hexutil.Big)(big.NewInt(int64(genBlocks + 3)))
+	opts := SimOpts{
+		BlockStateCalls: []SimBlock{
+			{Calls: calls},
+			// Leaves a gap of one block
+			{BlockOverrides: &BlockOverrides{Number: number}, Calls: calls},
+		},
+	}
+	blocks, err := api.SimulateV1(context.Background(), opts, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	if len(blocks) != 3 {
+		t.Fatalf("wrong number of blocks: have %d, want 3", len(blocks))
+	}
+	parent := head.Hash()
+	for i, block := range blocks {
+		if block["parentHash"] != parent {
+			t.Errorf("block %d: wrong parent: have %v, want %v", i, block["parentHash"], parent)
+		}
+		if have, want := block["number"].(*hexutil.Big).ToInt().Int64(), int64(genBlocks+1+i); have != want {
+			t.Errorf("block %d: wrong number: have %d, want %d", i, have, want)
+		}
+		results := block["calls"].([]CallStepResult)
+		if i == 1 {
+			if len(results) != 0 {
+				t.Errorf("gap block has calls: %v", results)
+			}
+			parent = block["hash"].(common.Hash)
+			continue
+		}
+		if len(results) != 2 || len(block["receipts"].([]map[string]interface{})) != 2 {
+			t.Fatalf("block %d: wrong number of calls or receipts", i)
+		}
+		if have, want := new(big.Int).SetBytes(results[0].ReturnData).Int64(), int64(i/2+1); have != want {
+			t.Errorf("block %d: wrong counter: have %d, want %d", i, have, want)
+		}
+		if len(results[0].Logs) != 1 || results[0].Logs[0].BlockHash != block["hash"] {
+			t.Errorf("block %d: wrong logs: %v", i, results[0].Logs)
+		}
+		if have := common.BytesToHash(results[1].ReturnData); have != parent {
+			t.Errorf("block %d: wrong parent block hash: have %v, want %v", i, have, parent)
+		}
+		parent = block["hash"].(common.Hash)
+	}
+	// With validation, the calls must pay the base fee and use the right nonce
+	nonce := hexutil.Uint64(1)
+	for i, call := range []TransactionArgs{
+		{From: &accounts[0].addr, To: &counter},
+		{From: &accounts[0].addr, To: &counter, MaxFeePerGas: (*hexutil.Big)(big.NewInt(params.InitialBaseFee)), Nonce: &nonce},
+	} {
+		opts := SimOpts{BlockStateCalls: []SimBlock{{Calls: []TransactionArgs{call}}}, Validation: true}
+		if _, err := api.SimulateV1(context.Background(), opts, nil); err == nil {
+			t.Errorf("invalid call %d: want error, have nothing", i)
+		}
+	}
+	valid := TransactionArgs{From: &accounts[0].addr, To: &counter, MaxFeePerGas: (*hexutil.Big)(big.NewInt(params.InitialBaseFee))}
+	opts = SimOpts{BlockStateCalls: []SimBlock{{Calls: []TransactionArgs{valid, valid}}}, Validation: true}
+	if _, err := api.SimulateV1(context.Background(), opts, nil); err != nil {
+		t.Errorf("valid calls rejected: %v", err)
+	}
+	// Identical calls from different senders keep their own logs, and a call
+	// with a gas price and an access list keeps both
+	var (
+		gas      = hexutil.Uint64(100_000)
+		gasPrice = (*hexutil.Big)(big.NewInt(params.InitialBaseFee))
+	)
+	opts = SimOpts{BlockStateCalls: []SimBlock{{Calls: []TransactionArgs{
+		{From: &accounts[0].addr, To: &counter, Gas: &gas},
+		{From: &accounts[1].addr, To: &counter, Gas: &gas},
+		{From: &accounts[0].addr, To: &counter, GasPrice: gasPrice, AccessList: &types.AccessList{{Address: counter}}},
+	}}}}
+	blocks, err = api.SimulateV1(context.Background(), opts, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	for i, res := range blocks[0]["calls"].([]CallStepResult) {
+		if len(res.Logs) != 1 || res.Logs[0].TxIndex != uint(i) {
+			t.Errorf("call %d: wrong logs: %v", i, res.Logs)
+		}
+	}
+	if have := blocks[0]["receipts"].([]map[string]interface{})[2]["type"]; have != hexutil.Uint(types.AccessListTxType) {
+		t.Errorf("call with an access list: wrong transaction type: have %v, want %d", have, types.AccessListTxType)
+	}
+}
+
 type Account struct {
 	key  *ecdsa.PrivateKey
 	addr common.Address
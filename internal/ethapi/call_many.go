@@ -0,0 +1,179 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// CallStep is a single step of eth_callMany: either a call, executed like
+// eth_call, or a signed transaction, executed with the nonce and balance
+// checks of a real transaction. The block overrides only apply to this step.
+type CallStep struct {
+	Call           *TransactionArgs `json:"call,omitempty"`
+	Transaction    hexutil.Bytes    `json:"transaction,omitempty"`
+	BlockOverrides *BlockOverrides  `json:"blockOverrides,omitempty"`
+}
+
+// CallStepResult is the outcome of a single step of eth_callMany. A step that
+// fails or reverts does not abort the following ones; its error is reported
+// instead, along with the revert data if any.
+type CallStepResult struct {
+	ReturnData hexutil.Bytes  `json:"returnData"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	Logs       []*types.Log   `json:"logs"`
+	Error      string         `json:"error,omitempty"`
+	RevertData hexutil.Bytes  `json:"revertData,omitempty"`
+}
+
+// CallMany executes the given steps in order on the state of the given block,
+// each step seeing the state changes of the previous ones. The state overrides
+// are applied once, before the first step.
+//
+// Note, this function doesn't make any changes in the state/blockchain and is
+// useful to simulate a sequence of transactions, such as a bundle.
+func (s *BlockChainAPI) CallMany(ctx context.Context, steps []CallStep, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride) ([]CallStepResult, error) {
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	return DoCallMany(ctx, s.b, steps, *blockNrOrHash, overrides, s.b.RPCEVMTimeout(), s.b.RPCGasCap())
+}
+
+// CallBundle executes the signed transactions in order on the state of the
+// given block, as eth_callMany does.
+func (s *BlockChainAPI) CallBundle(ctx context.Context, txs []hexutil.Bytes, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride) ([]CallStepResult, error) {
+	steps := make([]CallStep, len(txs))
+	for i, tx := range txs {
+		if len(tx) == 0 {
+			return nil, fmt.Errorf("transaction %d: empty transaction", i)
+		}
+		steps[i].Transaction = tx
+	}
+	return s.CallMany(ctx, steps, blockNrOrHash, overrides)
+}
+
+// DoCallMany executes the steps in order on the state of the given block. The
+// timeout, if set, bounds the execution of all the steps together, and the gas
+// cap applies to each call.
+func DoCallMany(ctx context.Context, b Backend, steps []CallStep, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, timeout time.Duration, globalGasCap uint64) ([]CallStepResult, error) {
+	defer func(start time.Time) { log.Debug("Executing EVM call many finished", "runtime", time.Since(start)) }(time.Now())
+
+	// Decode all the transactions upfront, to not waste an execution on
+	// malformed input.
+	txs := make([]*types.Transaction, len(steps))
+	for i, step := range steps {
+		if (step.Call == nil) == (len(step.Transaction) == 0) {
+			return nil, fmt.Errorf("step %d: exactly one of call and transaction must be given", i)
+		}
+		if len(step.Transaction) > 0 {
+			txs[i] = new(types.Transaction)
+			if err := txs[i].UnmarshalBinary(step.Transaction); err != nil {
+				return nil, fmt.Errorf("step %d: %w", i, err)
+			}
+		}
+	}
+	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	if err := overrides.Apply(state); err != nil {
+		return nil, err
+	}
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	results := make([]CallStepResult, len(steps))
+	for i, step := range steps {
+		var remaining time.Duration
+		if timeout > 0 {
+			if remaining = time.Until(deadline); remaining <= 0 {
+				return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+			}
+		}
+		var (
+			msg    *core.Message
+			txHash common.Hash
+		)
+		if tx := txs[i]; tx != nil {
+			signer := types.MakeSigner(b.ChainConfig(), header.Number, header.Time)
+			if msg, err = core.TransactionToMessage(tx, signer, header.BaseFee); err != nil {
+				results[i] = CallStepResult{Logs: []*types.Log{}, Error: err.Error()}
+				continue
+			}
+			txHash = tx.Hash()
+		} else {
+			if msg, err = step.Call.ToMessage(globalGasCap, header.BaseFee); err != nil {
+				return nil, fmt.Errorf("step %d: %w", i, err)
+			}
+			// Calls have no hash, key their logs by step instead.
+			txHash = common.BigToHash(big.NewInt(int64(i + 1)))
+		}
+		state.SetTxContext(txHash, i)
+
+		snap := state.Snapshot()
+		result, err := applyMessage(ctx, b, msg, state, header, step.BlockOverrides, remaining)
+		if err != nil {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if timeout > 0 && !time.Now().Before(deadline) {
+				return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+			}
+			// Errors that abort the execution before it starts, such as a
+			// wrong nonce or too little gas, are reported and the changes
+			// made so far, such as the gas bought, rolled back.
+			state.RevertToSnapshot(snap)
+			results[i] = CallStepResult{Logs: []*types.Log{}, Error: err.Error()}
+			continue
+		}
+		logs := state.GetLogs(txHash, header.Number.Uint64(), common.Hash{})
+		if txs[i] == nil {
+			for _, l := range logs {
+				l.TxHash = common.Hash{}
+			}
+		}
+		if logs == nil {
+			logs = []*types.Log{}
+		}
+		res := CallStepResult{
+			ReturnData: result.Return(),
+			GasUsed:    hexutil.Uint64(result.UsedGas),
+			Logs:       logs,
+		}
+		if len(result.Revert()) > 0 {
+			res.Error = NewRevertError(result).Error()
+			res.RevertData = result.Revert()
+		} else if result.Err != nil {
+			res.Error = result.Err.Error()
+		}
+		results[i] = res
+		state.Finalise(true)
+	}
+	return results, nil
+}
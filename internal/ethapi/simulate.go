@@ -0,0 +1,484 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// maxSimulateBlocks is the maximum number of blocks, including the ones
+	// filling gaps between block numbers, that can be simulated at once.
+	maxSimulateBlocks = 256
+
+	// simulateBlockTime is the time between simulated blocks whose timestamp
+	// is not overridden.
+	simulateBlockTime = 12
+)
+
+// SimBlock is a block to simulate: the overrides of its header fields, the
+// state overrides applied before its calls, and the calls themselves.
+type SimBlock struct {
+	BlockOverrides *BlockOverrides   `json:"blockOverrides"`
+	StateOverrides *StateOverride    `json:"stateOverrides"`
+	Calls          []TransactionArgs `json:"calls"`
+}
+
+// SimOpts are the inputs of eth_simulateV1. With Validation set, the calls are
+// checked like the transactions of a real block: nonces, balances, base fees
+// and the block gas limit.
+type SimOpts struct {
+	BlockStateCalls        []SimBlock `json:"blockStateCalls"`
+	Validation             bool       `json:"validation"`
+	ReturnFullTransactions bool       `json:"returnFullTransactions"`
+}
+
+// simChainContext is the chain as seen by the simulated blocks: the headers
+// of the blocks simulated so far are returned on top of the local chain, so
+// that BLOCKHASH resolves to the simulated hashes.
+type simChainContext struct {
+	core.ChainContext
+	headers map[uint64]*types.Header
+}
+
+func (c *simChainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header, ok := c.headers[number]; ok {
+		if header.Hash() != hash {
+			return nil
+		}
+		return header
+	}
+	return c.ChainContext.GetHeader(hash, number)
+}
+
+// simulatedBlock is a block produced by the simulator, with the receipts and
+// the results of its calls. The transactions of the block are not signed,
+// their senders are kept aside.
+type simulatedBlock struct {
+	block    *types.Block
+	receipts []*types.Receipt
+	calls    []CallStepResult
+	senders  []common.Address
+}
+
+// simulator executes a sequence of blocks on top of a base block, carrying the
+// state from one block to the next.
+type simulator struct {
+	b        Backend
+	state    *state.StateDB
+	base     *types.Header
+	chain    *simChainContext
+	validate bool
+	fullTx   bool
+	gasCap   uint64
+	calls    int64 // Calls executed so far, across all blocks
+}
+
+// SimulateV1 executes a sequence of blocks on top of the given block and
+// returns them, with the receipts and the results of their calls. Gaps between
+// the numbers of the blocks are filled with empty blocks.
+//
+// Note, this function doesn't make any changes in the state/blockchain.
+func (s *BlockChainAPI) SimulateV1(ctx context.Context, opts SimOpts, blockNrOrHash *rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	if len(opts.BlockStateCalls) == 0 {
+		return nil, errors.New("empty input")
+	}
+	if len(opts.BlockStateCalls) > maxSimulateBlocks {
+		return nil, fmt.Errorf("too many blocks: %d > %d", len(opts.BlockStateCalls), maxSimulateBlocks)
+	}
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	state, base, err := s.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	// Setup context so it may be cancelled the simulation has completed
+	// or, in case of unmetered gas, setup a context with a timeout.
+	var cancel context.CancelFunc
+	if timeout := s.b.RPCEVMTimeout(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	sim := &simulator{
+		b:        s.b,
+		state:    state,
+		base:     base,
+		chain:    &simChainContext{ChainContext: NewChainContext(ctx, s.b), headers: make(map[uint64]*types.Header)},
+		validate: opts.Validation,
+		fullTx:   opts.ReturnFullTransactions,
+		gasCap:   s.b.RPCGasCap(),
+	}
+	return sim.execute(ctx, opts.BlockStateCalls)
+}
+
+func (sim *simulator) execute(ctx context.Context, blocks []SimBlock) ([]map[string]interface{}, error) {
+	blocks, err := sim.sanitize(blocks)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		parent  = sim.base
+		results = make([]map[string]interface{}, len(blocks))
+	)
+	for i := range blocks {
+		sb, err := sim.processBlock(ctx, &blocks[i], parent)
+		if err != nil {
+			return nil, err
+		}
+		parent = sb.block.Header()
+		sim.chain.headers[parent.Number.Uint64()] = parent
+		results[i] = sim.marshalBlock(sb)
+	}
+	return results, nil
+}
+
+// sanitize sets the number and time of the blocks whose overrides omit them,
+// checks they increase, and fills the gaps between block numbers.
+func (sim *simulator) sanitize(blocks []SimBlock) ([]SimBlock, error) {
+	var (
+		res        = make([]SimBlock, 0, len(blocks))
+		prevNumber = new(big.Int).Set(sim.base.Number)
+		prevTime   = sim.base.Time
+	)
+	for _, block := range blocks {
+		overrides := new(BlockOverrides)
+		if block.BlockOverrides != nil {
+			*overrides = *block.BlockOverrides
+		}
+		block.BlockOverrides = overrides
+
+		if overrides.Number == nil {
+			overrides.Number = (*hexutil.Big)(new(big.Int).Add(prevNumber, common.Big1))
+		}
+		number := overrides.Number.ToInt()
+		if number.Cmp(prevNumber) <= 0 {
+			return nil, fmt.Errorf("block numbers must be in order: %d <= %d", number, prevNumber)
+		}
+		if span := new(big.Int).Sub(number, sim.base.Number); span.Cmp(big.NewInt(maxSimulateBlocks)) > 0 {
+			return nil, fmt.Errorf("too many blocks: %d > %d", span, maxSimulateBlocks)
+		}
+		// Fill the gap with empty blocks
+		for n := new(big.Int).Add(prevNumber, common.Big1); n.Cmp(number) < 0; n.Add(n, common.Big1) {
+			prevTime += simulateBlockTime
+			t := hexutil.Uint64(prevTime)
+			res = append(res, SimBlock{BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(new(big.Int).Set(n)), Time: &t}})
+		}
+		if overrides.Time == nil {
+			t := hexutil.Uint64(prevTime + simulateBlockTime)
+			overrides.Time = &t
+		} else if uint64(*overrides.Time) <= prevTime {
+			return nil, fmt.Errorf("block timestamps must be in order: %d <= %d", *overrides.Time, prevTime)
+		}
+		prevNumber, prevTime = number, uint64(*overrides.Time)
+		res = append(res, block)
+	}
+	return res, nil
+}
+
+// makeHeader derives the header of a simulated block from its parent and the
+// overrides, before the execution of its calls.
+func (sim *simulator) makeHeader(parent *types.Header, overrides *BlockOverrides) *types.Header {
+	config := sim.b.ChainConfig()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		UncleHash:  types.EmptyUncleHash,
+		Coinbase:   parent.Coinbase,
+		Difficulty: new(big.Int).Set(parent.Difficulty),
+		Number:     overrides.Number.ToInt(),
+		GasLimit:   parent.GasLimit,
+		Time:       uint64(*overrides.Time),
+	}
+	if overrides.Coinbase != nil {
+		header.Coinbase = *overrides.Coinbase
+	}
+	if overrides.Difficulty != nil {
+		header.Difficulty = overrides.Difficulty.ToInt()
+	}
+	if overrides.GasLimit != nil {
+		header.GasLimit = uint64(*overrides.GasLimit)
+	}
+	if overrides.Random != nil {
+		header.MixDigest = *overrides.Random
+	}
+	if config.IsLondon(header.Number) {
+		switch {
+		case overrides.BaseFee != nil:
+			header.BaseFee = overrides.BaseFee.ToInt()
+		case sim.validate:
+			header.BaseFee = eip1559.CalcBaseFee(config, parent)
+		default:
+			header.BaseFee = new(big.Int)
+		}
+	}
+	if config.IsShanghai(header.Number, header.Time) {
+		header.WithdrawalsHash = &types.EmptyWithdrawalsHash
+	}
+	if config.IsCancun(header.Number, header.Time) {
+		var excessBlobGas uint64
+		if parent.ExcessBlobGas != nil && parent.BlobGasUsed != nil {
+			excessBlobGas = eip4844.CalcExcessBlobGas(*parent.ExcessBlobGas, *parent.BlobGasUsed)
+		}
+		header.ExcessBlobGas = &excessBlobGas
+		header.BlobGasUsed = new(uint64)
+		header.ParentBeaconRoot = new(common.Hash)
+	}
+	return header
+}
+
+// processBlock executes the calls of a block on top of the simulation state,
+// and returns the resulting block.
+func (sim *simulator) processBlock(ctx context.Context, block *SimBlock, parent *types.Header) (*simulatedBlock, error) {
+	var (
+		config = sim.b.ChainConfig()
+		header = sim.makeHeader(parent, block.BlockOverrides)
+	)
+	if err := block.StateOverrides.Apply(sim.state); err != nil {
+		return nil, err
+	}
+	blockCtx := core.NewEVMBlockContext(header, sim.chain, &header.Coinbase)
+	if block.BlockOverrides.BlobBaseFee != nil {
+		blockCtx.BlobBaseFee = block.BlockOverrides.BlobBaseFee.ToInt()
+	}
+	vmConfig := vm.Config{NoBaseFee: !sim.validate}
+	if header.ParentBeaconRoot != nil {
+		evm := vm.NewEVM(blockCtx, vm.TxContext{GasPrice: new(big.Int)}, sim.state, config, vmConfig)
+		core.ProcessBeaconBlockRoot(*header.ParentBeaconRoot, evm, sim.state)
+	}
+	var (
+		gp       = new(core.GasPool).AddGas(header.GasLimit)
+		usedGas  uint64
+		txs      = make([]*types.Transaction, len(block.Calls))
+		receipts = make([]*types.Receipt, len(block.Calls))
+		results  = make([]CallStepResult, len(block.Calls))
+		senders  = make([]common.Address, len(block.Calls))
+	)
+	for i := range block.Calls {
+		tx, msg, err := sim.toMessage(&block.Calls[i], header, gp)
+		if err != nil {
+			return nil, fmt.Errorf("block %d, call %d: %w", header.Number, i, err)
+		}
+		// The unsigned transactions of calls from different senders may be
+		// identical, key the logs of each call by its position instead.
+		sim.calls++
+		logsKey := common.BigToHash(big.NewInt(sim.calls))
+		sim.state.SetTxContext(logsKey, i)
+
+		// Each call gets its own EVM, so that calls without gas price run with
+		// a zero base fee when not validating, as eth_call does.
+		evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), sim.state, config, vmConfig)
+		result, err := sim.applyMessage(ctx, evm, msg, gp)
+		if evm.Cancelled() {
+			return nil, fmt.Errorf("execution aborted (timeout = %v)", sim.b.RPCEVMTimeout())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("block %d, call %d: %w", header.Number, i, err)
+		}
+		var root []byte
+		if config.IsByzantium(header.Number) {
+			sim.state.Finalise(true)
+		} else {
+			root = sim.state.IntermediateRoot(config.IsEIP158(header.Number)).Bytes()
+		}
+		usedGas += result.UsedGas
+
+		receipt := &types.Receipt{
+			Type:              tx.Type(),
+			PostState:         root,
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: usedGas,
+			TxHash:            tx.Hash(),
+			GasUsed:           result.UsedGas,
+			EffectiveGasPrice: msg.GasPrice,
+			BlockNumber:       header.Number,
+			TransactionIndex:  uint(i),
+		}
+		if result.Failed() {
+			receipt.Status = types.ReceiptStatusFailed
+		}
+		if msg.To == nil {
+			receipt.ContractAddress = crypto.CreateAddress(msg.From, msg.Nonce)
+		}
+		receipt.Logs = sim.state.GetLogs(logsKey, header.Number.Uint64(), common.Hash{})
+		for _, l := range receipt.Logs {
+			l.TxHash = tx.Hash()
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+		res := CallStepResult{
+			ReturnData: result.Return(),
+			GasUsed:    hexutil.Uint64(result.UsedGas),
+			Logs:       receipt.Logs,
+		}
+		if res.Logs == nil {
+			res.Logs = []*types.Log{}
+		}
+		if len(result.Revert()) > 0 {
+			res.Error = NewRevertError(result).Error()
+			res.RevertData = result.Revert()
+		} else if result.Err != nil {
+			res.Error = result.Err.Error()
+		}
+		txs[i], receipts[i], results[i], senders[i] = tx, receipt, res, msg.From
+	}
+	header.GasUsed = usedGas
+	header.Root = sim.state.IntermediateRoot(config.IsEIP158(header.Number))
+
+	var b *types.Block
+	if header.WithdrawalsHash != nil {
+		b = types.NewBlockWithWithdrawals(header, txs, nil, receipts, []*types.Withdrawal{}, trie.NewStackTrie(nil))
+	} else {
+		b = types.NewBlock(header, txs, nil, receipts, trie.NewStackTrie(nil))
+	}
+	// The hash of the block is only known now, fill it in the receipts and logs
+	hash := b.Hash()
+	for _, receipt := range receipts {
+		receipt.BlockHash = hash
+		for _, l := range receipt.Logs {
+			l.BlockHash = hash
+		}
+	}
+	return &simulatedBlock{block: b, receipts: receipts, calls: results, senders: senders}, nil
+}
+
+// applyMessage executes msg, cancelling the execution when ctx is done.
+func (sim *simulator) applyMessage(ctx context.Context, evm *vm.EVM, msg *core.Message, gp *core.GasPool) (*core.ExecutionResult, error) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			evm.Cancel()
+		case <-done:
+		}
+	}()
+	return core.ApplyMessage(evm, msg, gp)
+}
+
+// toMessage converts a call to the message executed and the unsigned
+// transaction included in the simulated block. The nonce defaults to the one
+// of the sender and the gas to what is left in the block.
+func (sim *simulator) toMessage(args *TransactionArgs, header *types.Header, gp *core.GasPool) (*types.Transaction, *core.Message, error) {
+	from := args.from()
+	nonce := sim.state.GetNonce(from)
+	if args.Nonce != nil {
+		nonce = uint64(*args.Nonce)
+	}
+	if args.Gas == nil {
+		gas := gp.Gas()
+		if sim.gasCap != 0 && sim.gasCap < gas {
+			gas = sim.gasCap
+		}
+		args.Gas = (*hexutil.Uint64)(&gas)
+	}
+	msg, err := args.ToMessage(sim.gasCap, header.BaseFee)
+	if err != nil {
+		return nil, nil, err
+	}
+	msg.Nonce = nonce
+	msg.SkipAccountChecks = !sim.validate
+
+	// The transaction type follows the fields of the call, so that none of
+	// them is lost in the block.
+	var data types.TxData
+	switch {
+	case args.GasPrice == nil && (header.BaseFee != nil || args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil):
+		gasFeeCap, gasTipCap := new(big.Int), new(big.Int)
+		if args.MaxFeePerGas != nil {
+			gasFeeCap = args.MaxFeePerGas.ToInt()
+		}
+		if args.MaxPriorityFeePerGas != nil {
+			gasTipCap = args.MaxPriorityFeePerGas.ToInt()
+		}
+		data = &types.DynamicFeeTx{
+			ChainID:    sim.b.ChainConfig().ChainID,
+			Nonce:      nonce,
+			GasTipCap:  gasTipCap,
+			GasFeeCap:  gasFeeCap,
+			Gas:        msg.GasLimit,
+			To:         msg.To,
+			Value:      msg.Value,
+			Data:       msg.Data,
+			AccessList: msg.AccessList,
+		}
+	case args.AccessList != nil:
+		data = &types.AccessListTx{
+			ChainID:    sim.b.ChainConfig().ChainID,
+			Nonce:      nonce,
+			GasPrice:   msg.GasPrice,
+			Gas:        msg.GasLimit,
+			To:         msg.To,
+			Value:      msg.Value,
+			Data:       msg.Data,
+			AccessList: msg.AccessList,
+		}
+	default:
+		data = &types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: msg.GasPrice,
+			Gas:      msg.GasLimit,
+			To:       msg.To,
+			Value:    msg.Value,
+			Data:     msg.Data,
+		}
+	}
+	return types.NewTx(data), msg, nil
+}
+
+// marshalBlock converts a simulated block to its RPC representation, with
+// the receipts and the results of its calls. The senders of the unsigned
+// transactions are filled in from the calls.
+func (sim *simulator) marshalBlock(sb *simulatedBlock) map[string]interface{} {
+	var (
+		config   = sim.b.ChainConfig()
+		block    = sb.block
+		fields   = RPCMarshalBlock(block, true, sim.fullTx, config)
+		signer   = types.MakeSigner(config, block.Number(), block.Time())
+		receipts = make([]map[string]interface{}, len(sb.receipts))
+	)
+	if sim.fullTx {
+		for i, tx := range fields["transactions"].([]interface{}) {
+			tx.(*RPCTransaction).From = sb.senders[i]
+		}
+	}
+	for i, tx := range block.Transactions() {
+		receipts[i] = marshalReceipt(sb.receipts[i], block.Hash(), block.NumberU64(), signer, tx, i)
+		receipts[i]["from"] = sb.senders[i]
+	}
+	fields["receipts"] = receipts
+	fields["calls"] = sb.calls
+	return fields
+}
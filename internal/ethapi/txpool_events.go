@@ -0,0 +1,134 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// TxPoolEventFilter selects the transactions whose events are delivered by the
+// events subscription. A transaction matches if its sender is in From and its
+// recipient in To, an empty list matching any.
+type TxPoolEventFilter struct {
+	From []common.Address `json:"from"`
+	To   []common.Address `json:"to"`
+}
+
+// matches returns whether the event is about a transaction selected by the filter.
+func (f *TxPoolEventFilter) matches(ev txpool.TxEvent) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.From) > 0 && !includes(f.From, ev.From) {
+		return false
+	}
+	if len(f.To) > 0 && (ev.Tx.To() == nil || !includes(f.To, *ev.Tx.To())) {
+		return false
+	}
+	return true
+}
+
+// includes returns whether the given address is in the list.
+func includes(addresses []common.Address, a common.Address) bool {
+	for _, addr := range addresses {
+		if addr == a {
+			return true
+		}
+	}
+	return false
+}
+
+// RPCTxPoolEvent is a transition of a pooled transaction as delivered to the
+// subscribers of the events subscription.
+type RPCTxPoolEvent struct {
+	Type        string          `json:"type"`
+	Hash        common.Hash     `json:"hash"`
+	Transaction *RPCTransaction `json:"transaction"`
+	Status      string          `json:"status,omitempty"`     // Set for added events, pending or queued
+	ReplacedBy  *common.Hash    `json:"replacedBy,omitempty"` // Set for replaced events
+	Reason      string          `json:"reason,omitempty"`     // Set for dropped events
+}
+
+// Events creates a subscription that is triggered on each transition of the
+// pooled transactions matching the filter: their addition, their promotion to
+// and demotion from the pending set, their replacement, and their removal from
+// the pool either by a drop or by inclusion in a block.
+func (s *TxPoolAPI) Events(ctx context.Context, filter *TxPoolEventFilter) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan []txpool.TxEvent, 128)
+		eventsSub := s.b.SubscribeTxPoolEvents(events)
+		defer eventsSub.Unsubscribe()
+
+		for {
+			select {
+			case events := <-events:
+				current := s.b.CurrentHeader()
+				for _, ev := range events {
+					if !filter.matches(ev) {
+						continue
+					}
+					notifier.Notify(rpcSub.ID, newRPCTxPoolEvent(ev, s.b, current))
+				}
+			case err := <-eventsSub.Err():
+				// The pool dropped the subscription for falling behind
+				if err != nil {
+					log.Debug("Transaction pool events subscription ended", "err", err)
+				}
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// newRPCTxPoolEvent converts a pool event into its RPC representation.
+func newRPCTxPoolEvent(ev txpool.TxEvent, b Backend, current *types.Header) *RPCTxPoolEvent {
+	res := &RPCTxPoolEvent{
+		Type:        ev.Type.String(),
+		Hash:        ev.Tx.Hash(),
+		Transaction: NewRPCPendingTransaction(ev.Tx, current, b.ChainConfig()),
+	}
+	switch ev.Type {
+	case txpool.TxEventAdded:
+		if ev.Pending {
+			res.Status = "pending"
+		} else {
+			res.Status = "queued"
+		}
+	case txpool.TxEventReplaced:
+		res.ReplacedBy = &ev.ReplacedBy
+	case txpool.TxEventDropped:
+		res.Reason = ev.Reason.String()
+	}
+	return res
+}
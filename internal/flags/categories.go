@@ -34,6 +34,7 @@ const (
 	VMCategory         = "VIRTUAL MACHINE"
 	LoggingCategory    = "LOGGING AND DEBUGGING"
 	MetricsCategory    = "METRICS AND STATS"
+	SealerCategory     = "SEALER"
 	MiscCategory       = "MISC"
 	DeprecatedCategory = "ALIASED (deprecated)"
 )
@@ -0,0 +1,111 @@
+package sealer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// BlobSidecar carries the blobs of a caller supplied blob transaction, which
+// are not part of the JSON encoding of the transaction itself.
+type BlobSidecar struct {
+	TxHash      common.Hash     `json:"txHash"`
+	Blobs       []hexutil.Bytes `json:"blobs"`
+	Commitments []hexutil.Bytes `json:"commitments"`
+	Proofs      []hexutil.Bytes `json:"proofs"`
+}
+
+// toSidecar decodes the blobs and checks them against the versioned hashes.
+func (b *BlobSidecar) toSidecar(hashes []common.Hash) (*types.BlobTxSidecar, error) {
+	if len(b.Commitments) != len(b.Blobs) || len(b.Proofs) != len(b.Blobs) {
+		return nil, fmt.Errorf("mismatched number of blobs %d, commitments %d and proofs %d", len(b.Blobs), len(b.Commitments), len(b.Proofs))
+	}
+	sc := &types.BlobTxSidecar{
+		Blobs:       make([]kzg4844.Blob, len(b.Blobs)),
+		Commitments: make([]kzg4844.Commitment, len(b.Commitments)),
+		Proofs:      make([]kzg4844.Proof, len(b.Proofs)),
+	}
+	for i := range b.Blobs {
+		if len(b.Blobs[i]) != len(sc.Blobs[i]) {
+			return nil, fmt.Errorf("blob %d: invalid length %d", i, len(b.Blobs[i]))
+		}
+		if len(b.Commitments[i]) != len(sc.Commitments[i]) {
+			return nil, fmt.Errorf("commitment %d: invalid length %d", i, len(b.Commitments[i]))
+		}
+		if len(b.Proofs[i]) != len(sc.Proofs[i]) {
+			return nil, fmt.Errorf("proof %d: invalid length %d", i, len(b.Proofs[i]))
+		}
+		copy(sc.Blobs[i][:], b.Blobs[i])
+		copy(sc.Commitments[i][:], b.Commitments[i])
+		copy(sc.Proofs[i][:], b.Proofs[i])
+	}
+	if err := txpool.ValidateBlobSidecar(hashes, sc); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// blobSidecars decodes the sidecars supplied by the caller and matches them
+// with the blob transactions among txns and bundles.
+func blobSidecars(sidecars []*BlobSidecar, txns []*types.Transaction, bundles []*Bundle) (map[common.Hash]*types.BlobTxSidecar, error) {
+	blobTxs := make(map[common.Hash]*types.Transaction)
+	collect := func(txs []*types.Transaction) {
+		for _, tx := range txs {
+			if tx.Type() == types.BlobTxType {
+				blobTxs[tx.Hash()] = tx
+			}
+		}
+	}
+	collect(txns)
+	for _, bundle := range bundles {
+		collect(bundle.Transactions)
+	}
+	decoded := make(map[common.Hash]*types.BlobTxSidecar, len(sidecars))
+	for _, b := range sidecars {
+		tx, ok := blobTxs[b.TxHash]
+		if !ok {
+			return nil, fmt.Errorf("blob sidecar for unknown blob transaction %s", b.TxHash.Hex())
+		}
+		sc, err := b.toSidecar(tx.BlobHashes())
+		if err != nil {
+			return nil, fmt.Errorf("invalid blob sidecar for transaction %s: %w", b.TxHash.Hex(), err)
+		}
+		decoded[b.TxHash] = sc
+	}
+	return decoded, nil
+}
+
+// blobSidecar returns the sidecar of a blob transaction, checking that its blobs
+// still fit into the block. The blob gas limit is only checked at block
+// validation time, so core.ApplyTransaction would not catch it.
+func (s *Sealer) blobSidecar(env *environment, tx *types.Transaction) (*types.BlobTxSidecar, error) {
+	if tx.Type() != types.BlobTxType {
+		return nil, nil
+	}
+	if env.header.ExcessBlobGas == nil {
+		return nil, errors.New("blob transaction before cancun")
+	}
+	sc := env.sidecarOf(tx)
+	if sc == nil {
+		return nil, errors.New("missing blob sidecar")
+	}
+	if (env.blobs+len(sc.Blobs))*params.BlobTxBlobGasPerBlob > params.MaxBlobGasPerBlock {
+		return nil, errors.New("max data blobs reached")
+	}
+	return sc, nil
+}
+
+// sidecarOf returns the sidecar of a blob transaction, either carried by the
+// transaction itself when it comes from the pool, or supplied by the caller.
+func (env *environment) sidecarOf(tx *types.Transaction) *types.BlobTxSidecar {
+	if sc := tx.BlobTxSidecar(); sc != nil {
+		return sc
+	}
+	return env.blobSidecars[tx.Hash()]
+}
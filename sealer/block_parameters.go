@@ -0,0 +1,69 @@
+package sealer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// checkTimestamp rejects a block timestamp that is not after its parent.
+func checkTimestamp(parent *types.Header, timestamp uint64) error {
+	if timestamp <= parent.Time {
+		return InvalidTimestamp.With(fmt.Errorf("parent %d given %d", parent.Time, timestamp))
+	}
+	return nil
+}
+
+// gasLimit returns the gas limit of the block to seal on top of parent: the
+// one requested if valid, or else the closest allowed to the gas target.
+func (s *Sealer) gasLimit(p *BlockParameters, parent *types.Header) (uint64, error) {
+	// The limit doubles at the London fork to keep the same gas target.
+	parentGasLimit := parent.GasLimit
+	if s.chainConfig.IsLondon(new(big.Int).Add(parent.Number, common.Big1)) && !s.chainConfig.IsLondon(parent.Number) {
+		parentGasLimit = parent.GasLimit * s.chainConfig.ElasticityMultiplier()
+	}
+	if p.GasLimit == 0 {
+		target := p.GasTarget
+		if target == 0 {
+			target = s.config.GasCeil
+		}
+		return core.CalcGasLimit(parentGasLimit, target), nil
+	}
+	if p.GasTarget != 0 {
+		return 0, InvalidGasLimit.With(errors.New("both a gas limit and a gas target given"))
+	}
+	if err := misc.VerifyGaslimit(parentGasLimit, p.GasLimit); err != nil {
+		return 0, InvalidGasLimit.With(err)
+	}
+	return p.GasLimit, nil
+}
+
+// checkHeaderFields rejects the fields of the header which the fork rules of
+// the block to seal do not allow, before any transaction is executed.
+func (s *Sealer) checkHeaderFields(p *BlockParameters, header *types.Header) error {
+	if len(header.Extra) > int(params.MaximumExtraDataSize) {
+		return ExtraDataTooLong.With(fmt.Errorf("%d bytes, the maximum is %d", len(header.Extra), params.MaximumExtraDataSize))
+	}
+	shanghai := s.chainConfig.IsShanghai(header.Number, header.Time)
+	switch {
+	case shanghai && p.Withdrawals == nil:
+		return InvalidWithdrawals.With(errors.New("missing withdrawals after Shanghai"))
+	case !shanghai && p.Withdrawals != nil:
+		return InvalidWithdrawals.With(errors.New("withdrawals given before Shanghai"))
+	}
+	cancun := s.chainConfig.IsCancun(header.Number, header.Time)
+	switch {
+	case cancun && p.BeaconRoot == nil:
+		return InvalidBeaconRoot.With(errors.New("missing parent beacon block root after Cancun"))
+	case !cancun && p.BeaconRoot != nil:
+		return InvalidBeaconRoot.With(errors.New("parent beacon block root given before Cancun"))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package sealer
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestCheckBlockParameters(t *testing.T) {
+	var (
+		config = *params.TestChainConfig
+		zero   = uint64(0)
+		s      = &Sealer{chainConfig: &config, config: DefaultConfig}
+		parent = &types.Header{Number: big.NewInt(1), Time: 10, GasLimit: 30_000_000}
+	)
+	config.ShanghaiTime, config.CancunTime = &zero, &zero
+
+	tests := []struct {
+		name string
+		p    BlockParameters
+		want *SealerError
+	}{
+		{"valid", BlockParameters{Timestamp: 12, Withdrawals: []*types.Withdrawal{}, BeaconRoot: &common.Hash{}}, nil},
+		{"same timestamp", BlockParameters{Timestamp: 10, Withdrawals: []*types.Withdrawal{}, BeaconRoot: &common.Hash{}}, InvalidTimestamp},
+		{"gas limit out of bounds", BlockParameters{Timestamp: 12, GasLimit: 60_000_000}, InvalidGasLimit},
+		{"extra data too long", BlockParameters{Timestamp: 12, Extra: make([]byte, 33), Withdrawals: []*types.Withdrawal{}, BeaconRoot: &common.Hash{}}, ExtraDataTooLong},
+		{"missing withdrawals", BlockParameters{Timestamp: 12, BeaconRoot: &common.Hash{}}, InvalidWithdrawals},
+		{"missing beacon root", BlockParameters{Timestamp: 12, Withdrawals: []*types.Withdrawal{}}, InvalidBeaconRoot},
+	}
+	for _, test := range tests {
+		err := checkTimestamp(parent, test.p.Timestamp)
+		if err == nil {
+			var gasLimit uint64
+			if gasLimit, err = s.gasLimit(&test.p, parent); err == nil {
+				header := &types.Header{Number: big.NewInt(2), Time: test.p.Timestamp, GasLimit: gasLimit, Extra: test.p.Extra}
+				err = s.checkHeaderFields(&test.p, header)
+			}
+		}
+		var have *SealerError
+		if errors.As(err, &have) {
+			if test.want == nil || have.ErrorCode() != test.want.ErrorCode() {
+				t.Errorf("%s: wrong error: have %v, want %v", test.name, err, test.want)
+			}
+		} else if err != nil || test.want != nil {
+			t.Errorf("%s: wrong error: have %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestGasLimitTarget(t *testing.T) {
+	var (
+		s      = &Sealer{chainConfig: params.TestChainConfig, config: DefaultConfig}
+		parent = &types.Header{Number: big.NewInt(1), GasLimit: 30_000_000}
+	)
+	have, err := s.gasLimit(&BlockParameters{GasTarget: 40_000_000}, parent)
+	if err != nil {
+		t.Fatalf("failed to compute gas limit: %v", err)
+	}
+	if want := parent.GasLimit + parent.GasLimit/params.GasLimitBoundDivisor - 1; have != want {
+		t.Fatalf("wrong gas limit: have %d, want %d", have, want)
+	}
+	if _, err := s.gasLimit(&BlockParameters{GasLimit: 30_000_000, GasTarget: 40_000_000}, parent); err == nil {
+		t.Fatalf("gas limit and target accepted together")
+	}
+}
@@ -0,0 +1,154 @@
+package sealer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Bundle is an ordered group of transactions that is included in the sealed
+// block all-or-nothing.
+type Bundle struct {
+	Transactions []*types.Transaction `json:"txs"`
+}
+
+// executedBundle holds the transactions of a bundle executed so far, with
+// their outcome, before they are added to the block.
+type executedBundle struct {
+	txs      []*types.Transaction
+	receipts []*types.Receipt
+	results  []*core.ExecutionResult
+	traces   []json.RawMessage
+	profits  []*big.Int
+}
+
+// commitBundle executes the transactions of the bundle in order on top of env.
+// If any of them fails, or reverts without being listed as revertible, env is
+// rolled back to the state it had before the bundle and every transaction of
+// the bundle is returned as excluded.
+func (s *Sealer) commitBundle(env *environment, bundle *Bundle) []ExcludedTransaction {
+	// The state journal is cleared between transactions, so a state snapshot
+	// cannot span the whole bundle: keep a copy of the state to roll back to.
+	// A failed transaction is rolled back on its own, a bundle of one needs
+	// no copy.
+	var (
+		snap    *state.StateDB
+		gas     = env.gasPool.Gas()
+		gasUsed = env.header.GasUsed
+		blobs   = env.blobs
+	)
+	if len(bundle.Transactions) > 1 {
+		snap = env.state.Copy()
+	}
+	executed, failed, err := s.executeBundle(env, bundle)
+	if err != nil {
+		for i, tx := range executed.txs {
+			env.traceExcluded(tx, executed.traces[i])
+		}
+		if failed > 0 {
+			env.restoreState(snap)
+		}
+		env.gasPool.SetGas(gas)
+		env.header.GasUsed = gasUsed
+		env.blobs = blobs
+		return excludeBundle(bundle, failed, err)
+	}
+	for i, tx := range executed.txs {
+		env.include(tx, executed.receipts[i], executed.results[i], executed.traces[i], executed.profits[i])
+	}
+	return nil
+}
+
+// executeBundle executes the transactions of the bundle in order on top of env,
+// without adding them to the block, up to the first one that fails. The index
+// of the failed transaction is returned with its error, and its trace recorded.
+func (s *Sealer) executeBundle(env *environment, bundle *Bundle) (*executedBundle, int, error) {
+	n := len(bundle.Transactions)
+	executed := &executedBundle{
+		txs:      make([]*types.Transaction, 0, n),
+		receipts: make([]*types.Receipt, 0, n),
+		results:  make([]*core.ExecutionResult, 0, n),
+		traces:   make([]json.RawMessage, 0, n),
+		profits:  make([]*big.Int, 0, n),
+	}
+	for i, tx := range bundle.Transactions {
+		balance := env.coinbaseBalance()
+		receipt, result, traceBody, err := s.applyTransaction(env, tx, len(env.receipts)+i)
+		if err != nil {
+			env.traceExcluded(tx, traceBody)
+			return executed, i, err
+		}
+		executed.txs = append(executed.txs, tx)
+		executed.receipts = append(executed.receipts, receipt)
+		executed.results = append(executed.results, result)
+		executed.traces = append(executed.traces, traceBody)
+		executed.profits = append(executed.profits, env.coinbaseDelta(balance))
+	}
+	return executed, n, nil
+}
+
+// restoreState replaces the state of env with a copy taken earlier. The copy
+// only holds an inactive copy of the prefetcher, so the prefetcher of the
+// dropped state is stopped and a new one started on the copy.
+func (env *environment) restoreState(snap *state.StateDB) {
+	env.state.StopPrefetcher()
+	env.state = snap
+	env.state.StartPrefetcher("sealer")
+}
+
+// stopPrefetcher stops the prefetcher of the current state of env. The state
+// may be swapped for a copy when a bundle is rolled back, so a deferred call
+// must go through env rather than through the state it started with.
+func (env *environment) stopPrefetcher() {
+	env.state.StopPrefetcher()
+}
+
+// executionError returns the error of a failed execution, with the revert
+// reason decoded from the return data if the transaction reverted.
+func executionError(result *core.ExecutionResult) error {
+	if errors.Is(result.Err, vm.ErrExecutionReverted) {
+		return ethapi.NewRevertError(result)
+	}
+	return result.Err
+}
+
+// excludeBundle reports every transaction of a bundle whose failed-th
+// transaction could not be included.
+func excludeBundle(bundle *Bundle, failed int, err error) []ExcludedTransaction {
+	failedHash := bundle.Transactions[failed].Hash()
+	excluded := make([]ExcludedTransaction, len(bundle.Transactions))
+	for i, tx := range bundle.Transactions {
+		var reason string
+		switch {
+		case i == failed:
+			reason = err.Error()
+		case i < failed:
+			reason = fmt.Sprintf("bundle reverted: transaction %s failed", failedHash.Hex())
+		default:
+			reason = fmt.Sprintf("not executed: transaction %s of the bundle failed", failedHash.Hex())
+		}
+		excluded[i] = ExcludedTransaction{Hash: tx.Hash(), Reason: reason, category: "bundle"}
+	}
+	excluded[failed] = excludeTransaction(bundle.Transactions[failed], err)
+	return excluded
+}
+
+// excludeTransaction reports a transaction that could not be included.
+func excludeTransaction(tx *types.Transaction, err error) ExcludedTransaction {
+	excluded := ExcludedTransaction{Hash: tx.Hash(), Reason: err.Error(), category: exclusionCategory(err)}
+
+	// Keep the raw return data of a revert, the reason may be a custom error.
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		excluded.RevertData, _ = dataErr.ErrorData().(string)
+	}
+	return excluded
+}
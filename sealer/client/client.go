@@ -0,0 +1,119 @@
+// Package client provides an RPC client for the sealer API.
+package client
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/sealer"
+)
+
+// Client is a wrapper around rpc.Client that implements the sealer namespace.
+type Client struct {
+	c *rpc.Client
+}
+
+// New creates a client that uses the given RPC client.
+func New(c *rpc.Client) *Client {
+	return &Client{c}
+}
+
+// Dial connects a client to the given URL.
+func Dial(ctx context.Context, rawurl string) (*Client, error) {
+	c, err := rpc.DialContext(ctx, rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return New(c), nil
+}
+
+// DialWithJWT connects a client to the given URL, authenticating with the
+// JWT secret of the node as the authenticated endpoints require.
+func DialWithJWT(ctx context.Context, rawurl string, secret [32]byte) (*Client, error) {
+	c, err := rpc.DialOptions(ctx, rawurl, rpc.WithHTTPAuth(node.NewJWTAuth(secret)))
+	if err != nil {
+		return nil, err
+	}
+	return New(c), nil
+}
+
+// Close closes the underlying RPC connection.
+func (sc *Client) Close() {
+	sc.c.Close()
+}
+
+// Client gets the underlying RPC client.
+func (sc *Client) Client() *rpc.Client {
+	return sc.c
+}
+
+// SealBlock seals a block with the given parameters, starting with txns and
+// optionally filled with the mempool transactions. The options may be nil.
+func (sc *Client) SealBlock(ctx context.Context, p *sealer.BlockParameters, txns []*types.Transaction, fillWithMempool bool, trace bool, opts *sealer.SealOptions) (*sealer.SealedBlock, error) {
+	var result sealer.SealedBlock
+	if err := sc.c.CallContext(ctx, &result, "sealer_sealBlock", p, txns, fillWithMempool, trace, opts); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Simulate executes a seal request without assembling the block, on top of
+// the parent state with the overrides applied.
+func (sc *Client) Simulate(ctx context.Context, p *sealer.BlockParameters, txns []*types.Transaction, fillWithMempool bool, trace bool, opts *sealer.SealOptions, overrides map[common.Address]gethclient.OverrideAccount) (*sealer.SimulationResult, error) {
+	var result sealer.SimulationResult
+	if err := sc.c.CallContext(ctx, &result, "sealer_simulate", p, txns, fillWithMempool, trace, opts, overrides); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ValidateSealedBlock validates a block sealed by the node against the chain.
+func (sc *Client) ValidateSealedBlock(ctx context.Context, hash common.Hash) (*sealer.SealedBlockStatus, error) {
+	var result sealer.SealedBlockStatus
+	if err := sc.c.CallContext(ctx, &result, "sealer_validateSealedBlock", hash); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ImportSealedBlock inserts a block sealed by the node into the chain, without
+// making it the head.
+func (sc *Client) ImportSealedBlock(ctx context.Context, hash common.Hash) (*sealer.SealedBlockStatus, error) {
+	var result sealer.SealedBlockStatus
+	if err := sc.c.CallContext(ctx, &result, "sealer_importSealedBlock", hash); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetBulkAccountNoncesAndBalances returns the nonces and balances of the
+// accounts at the given block.
+func (sc *Client) GetBulkAccountNoncesAndBalances(ctx context.Context, accounts []common.Address, blockHash common.Hash) ([]sealer.NonceAndBalance, error) {
+	var result []sealer.NonceAndBalance
+	err := sc.c.CallContext(ctx, &result, "sealer_getBulkAccountNoncesAndBalances", accounts, blockHash)
+	return result, err
+}
+
+// GetBulkAccountState returns the state of the accounts and storage slots
+// requested at the given block, with their proofs if withProof is set.
+func (sc *Client) GetBulkAccountState(ctx context.Context, requests []sealer.AccountStateRequest, blockNrOrHash rpc.BlockNumberOrHash, withProof bool) ([]sealer.AccountState, error) {
+	var result []sealer.AccountState
+	err := sc.c.CallContext(ctx, &result, "sealer_getBulkAccountState", requests, blockNrOrHash, withProof)
+	return result, err
+}
+
+// SubscribeSealedBlocks subscribes to the blocks re-sealed from the template
+// on every new head and recommit interval. A zero recommit interval uses the
+// default of the node.
+func (sc *Client) SubscribeSealedBlocks(ctx context.Context, ch chan<- *sealer.SealedBlock, p *sealer.BlockParameters, txns []*types.Transaction, fillWithMempool bool, trace bool, opts *sealer.SealOptions, recommit uint64) (*rpc.ClientSubscription, error) {
+	var interval *hexutil.Uint64
+	if recommit != 0 {
+		interval = (*hexutil.Uint64)(&recommit)
+	}
+	return sc.c.Subscribe(ctx, "sealer", ch, "sealedBlocks", p, txns, fillWithMempool, trace, opts, interval)
+}
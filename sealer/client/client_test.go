@@ -0,0 +1,298 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/sealer"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testBalance = new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether))
+	testSecret  = [32]byte{0x5e, 0xc2, 0xe7}
+)
+
+// testBackend is an in-memory node on a merged chain with Cancun active from
+// genesis, serving the sealer API in-process and on the authenticated
+// endpoint.
+type testBackend struct {
+	node *node.Node
+	eth  *eth.Ethereum
+}
+
+func newTestBackend(t *testing.T) *testBackend {
+	t.Helper()
+
+	config := *params.AllEthashProtocolChanges
+	config.TerminalTotalDifficulty = common.Big0
+	config.TerminalTotalDifficultyPassed = true
+	config.ShanghaiTime = new(uint64)
+	config.CancunTime = new(uint64)
+
+	genesis := &core.Genesis{
+		Config: &config,
+		Alloc: core.GenesisAlloc{
+			testAddr:                         {Balance: testBalance},
+			params.BeaconRootsStorageAddress: {Balance: common.Big0, Code: common.Hex2Bytes("3373fffffffffffffffffffffffffffffffffffffffe14604457602036146024575f5ffd5b620180005f350680545f35146037575f5ffd5b6201800042064281555f359062018000015500")},
+		},
+		Timestamp:  9000,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+		Difficulty: common.Big0,
+	}
+	secret := filepath.Join(t.TempDir(), "jwt.hex")
+	if err := os.WriteFile(secret, []byte(hexutil.Encode(testSecret[:])), 0600); err != nil {
+		t.Fatal("can't write the jwt secret:", err)
+	}
+	n, err := node.New(&node.Config{
+		AuthAddr:  "127.0.0.1",
+		JWTSecret: secret,
+		P2P: p2p.Config{
+			ListenAddr:  "0.0.0.0:0",
+			NoDiscovery: true,
+			MaxPeers:    25,
+		}})
+	if err != nil {
+		t.Fatal("can't create node:", err)
+	}
+	ethcfg := &ethconfig.Config{Genesis: genesis, SyncMode: downloader.FullSync, TrieTimeout: time.Minute, TrieDirtyCache: 256, TrieCleanCache: 256}
+	ethservice, err := eth.New(n, ethcfg)
+	if err != nil {
+		t.Fatal("can't create eth service:", err)
+	}
+	cfg := sealer.DefaultConfig
+	cfg.Enabled = true
+	if err := sealer.Register(n, ethservice, &cfg); err != nil {
+		t.Fatal("can't register the sealer:", err)
+	}
+	if err := n.Start(); err != nil {
+		t.Fatal("can't start node:", err)
+	}
+	t.Cleanup(func() { n.Close() })
+
+	ethservice.SetSynced()
+	return &testBackend{node: n, eth: ethservice}
+}
+
+// client returns a client of the sealer API served in-process.
+func (b *testBackend) client(t *testing.T) *Client {
+	client := New(b.node.Attach())
+	t.Cleanup(client.Close)
+	return client
+}
+
+// params returns the parameters of a block on top of the head.
+func (b *testBackend) params() *sealer.BlockParameters {
+	head := b.eth.BlockChain().CurrentBlock()
+	return &sealer.BlockParameters{
+		ParentHash:  head.Hash(),
+		Coinbase:    common.Address{0xc0, 0x1b},
+		Timestamp:   head.Time + 12,
+		Random:      common.Hash{0x01},
+		Withdrawals: []*types.Withdrawal{},
+		BeaconRoot:  &common.Hash{42},
+	}
+}
+
+func transferTx(t *testing.T, key *ecdsa.PrivateKey, nonce uint64) *types.Transaction {
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(params.AllEthashProtocolChanges.ChainID), &types.DynamicFeeTx{
+		ChainID:   params.AllEthashProtocolChanges.ChainID,
+		Nonce:     nonce,
+		To:        &common.Address{0x02},
+		Gas:       params.TxGas,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(2*params.InitialBaseFee + 1),
+		Value:     big.NewInt(1),
+	})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	return tx
+}
+
+func TestSealBlock(t *testing.T) {
+	var (
+		b      = newTestBackend(t)
+		client = b.client(t)
+		tx     = transferTx(t, testKey, 0)
+	)
+	sb, err := client.SealBlock(context.Background(), b.params(), []*types.Transaction{tx}, false, false, &sealer.SealOptions{Strategies: []string{"callerFirst", "priceOnly"}})
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	if len(sb.Candidates) != 2 || sb.Profit.ToInt().Int64() != int64(params.TxGas) {
+		t.Fatalf("wrong sealed block: profit %v, candidates %v", sb.Profit, sb.Candidates)
+	}
+	if len(sb.ExecutableData.ExecutionPayload.Transactions) != 1 || len(sb.Receipts) != 1 || sb.Receipts[0].TxHash != tx.Hash() {
+		t.Fatalf("transaction not sealed: %v", sb.Receipts)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	var (
+		b         = newTestBackend(t)
+		client    = b.client(t)
+		key, _    = crypto.GenerateKey()
+		tx        = transferTx(t, key, 0)
+		overrides = map[common.Address]gethclient.OverrideAccount{
+			crypto.PubkeyToAddress(key.PublicKey): {Balance: testBalance},
+		}
+	)
+	res, err := client.Simulate(context.Background(), b.params(), []*types.Transaction{tx}, false, false, nil, overrides)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	if len(res.ExcludedTransactions) != 0 {
+		t.Fatalf("transaction of the overridden account excluded: %v", res.ExcludedTransactions)
+	}
+	if len(res.Transactions) != 1 || res.Transactions[0].Hash != tx.Hash() || res.Transactions[0].Status != hexutil.Uint64(types.ReceiptStatusSuccessful) {
+		t.Fatalf("wrong simulated transactions: %+v", res.Transactions)
+	}
+	if res.GasUsed != hexutil.Uint64(params.TxGas) {
+		t.Fatalf("wrong gas used: have %d, want %d", res.GasUsed, params.TxGas)
+	}
+}
+
+func TestValidateAndImportSealedBlock(t *testing.T) {
+	var (
+		b      = newTestBackend(t)
+		client = b.client(t)
+		ctx    = context.Background()
+	)
+	sb, err := client.SealBlock(ctx, b.params(), []*types.Transaction{transferTx(t, testKey, 0)}, false, false, nil)
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	hash := sb.ExecutableData.ExecutionPayload.BlockHash
+	status, err := client.ValidateSealedBlock(ctx, hash)
+	if err != nil {
+		t.Fatalf("failed to validate: %v", err)
+	}
+	if status.Hash != hash || !status.Valid {
+		t.Fatalf("sealed block invalid: %+v", status)
+	}
+	if status, err = client.ImportSealedBlock(ctx, hash); err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	if !status.Valid {
+		t.Fatalf("sealed block not imported: %+v", status)
+	}
+	if !b.eth.BlockChain().HasBlock(hash, sb.ExecutableData.ExecutionPayload.Number) {
+		t.Fatal("imported block not in the chain")
+	}
+	if _, err := client.ValidateSealedBlock(ctx, common.Hash{0x01}); err == nil {
+		t.Fatal("unknown block validated")
+	}
+}
+
+func TestGetBulkAccountNoncesAndBalances(t *testing.T) {
+	var (
+		b        = newTestBackend(t)
+		client   = b.client(t)
+		accounts = []common.Address{testAddr, {0x02}}
+	)
+	nnb, err := client.GetBulkAccountNoncesAndBalances(context.Background(), accounts, b.eth.BlockChain().CurrentBlock().Hash())
+	if err != nil {
+		t.Fatalf("failed to get accounts: %v", err)
+	}
+	if len(nnb) != len(accounts) {
+		t.Fatalf("wrong number of accounts: have %d, want %d", len(nnb), len(accounts))
+	}
+	for i, want := range []*big.Int{testBalance, common.Big0} {
+		if nnb[i].Address != accounts[i] || nnb[i].Nonce != 0 || nnb[i].Balance.ToInt().Cmp(want) != 0 {
+			t.Errorf("wrong account %d: %+v", i, nnb[i])
+		}
+	}
+}
+
+func TestGetBulkAccountState(t *testing.T) {
+	var (
+		b        = newTestBackend(t)
+		client   = b.client(t)
+		latest   = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		requests = []sealer.AccountStateRequest{{Address: testAddr, Slots: []common.Hash{{0x01}}}}
+	)
+	res, err := client.GetBulkAccountState(context.Background(), requests, latest, true)
+	if err != nil {
+		t.Fatalf("failed to get account state: %v", err)
+	}
+	if len(res) != 1 || res[0].Address != testAddr || res[0].Balance.ToInt().Cmp(testBalance) != 0 {
+		t.Fatalf("wrong account state: %+v", res)
+	}
+	if len(res[0].AccountProof) == 0 || len(res[0].StorageProof) != 1 {
+		t.Fatalf("proofs missing: %d account nodes, %d storage proofs", len(res[0].AccountProof), len(res[0].StorageProof))
+	}
+}
+
+func TestSubscribeSealedBlocks(t *testing.T) {
+	var (
+		b      = newTestBackend(t)
+		client = b.client(t)
+		ch     = make(chan *sealer.SealedBlock)
+		head   = b.eth.BlockChain().CurrentBlock()
+	)
+	// A zero recommit interval leaves it to the node.
+	sub, err := client.SubscribeSealedBlocks(context.Background(), ch, b.params(), nil, false, false, nil, 0)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	select {
+	case sb := <-ch:
+		if parent := sb.ExecutableData.ExecutionPayload.ParentHash; parent != head.Hash() {
+			t.Fatalf("block sealed on the wrong parent: have %v, want %v", parent, head.Hash())
+		}
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no sealed block received")
+	}
+}
+
+// Tests that the authenticated endpoint serves the sealer API to a client
+// dialed with the JWT secret of the node only.
+func TestDialWithJWT(t *testing.T) {
+	var (
+		b   = newTestBackend(t)
+		ctx = context.Background()
+	)
+	client, err := DialWithJWT(ctx, b.node.HTTPAuthEndpoint(), testSecret)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.SealBlock(ctx, b.params(), []*types.Transaction{}, false, false, nil); err != nil {
+		t.Fatalf("failed to seal block with the jwt secret: %v", err)
+	}
+
+	unauthenticated, err := Dial(ctx, b.node.HTTPAuthEndpoint())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer unauthenticated.Close()
+
+	if _, err := unauthenticated.SealBlock(ctx, b.params(), []*types.Transaction{}, false, false, nil); err == nil {
+		t.Fatal("sealed block without the jwt secret")
+	}
+}
@@ -1,6 +1,97 @@
 package sealer
 
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+)
+
 type SealerConfig struct {
 	Enabled  bool
 	Insecure bool
+
+	ExtraData          hexutil.Bytes    // Extra data of the sealed blocks when the request has none
+	MaxTxsPerRequest   int              // Maximum caller transactions per request, bundles included
+	MaxStateReads      int              // Maximum accounts and storage slots per bulk state request
+	SealTimeout        time.Duration    // Time after which no more transactions are added to a block
+	TraceTimeout       time.Duration    // Default and maximum time a tracer may spend on one transaction
+	AllowedCoinbases   []common.Address `toml:",omitempty"` // Coinbases that may be sealed for, any if empty
+	MaxConcurrentSeals int              // Maximum seal requests executed at the same time
+	GasCeil            uint64           // Gas limit to move toward when the request has none, the miner's if zero
+	SealedBlocks       int              // Sealed blocks kept with their state, to validate, import or seal on top of
+}
+
+// DefaultConfig contains the default settings of the sealer.
+var DefaultConfig = SealerConfig{
+	ExtraData:          []byte("Manifold"),
+	MaxTxsPerRequest:   1000,
+	MaxStateReads:      10000,
+	SealTimeout:        10 * time.Second,
+	TraceTimeout:       5 * time.Second,
+	MaxConcurrentSeals: 8,
+	SealedBlocks:       32,
+}
+
+// sanitize replaces the invalid settings with their defaults.
+func (cfg SealerConfig) sanitize() SealerConfig {
+	if cfg.MaxTxsPerRequest <= 0 {
+		cfg.MaxTxsPerRequest = DefaultConfig.MaxTxsPerRequest
+	}
+	if cfg.MaxStateReads <= 0 {
+		cfg.MaxStateReads = DefaultConfig.MaxStateReads
+	}
+	if cfg.SealTimeout <= 0 {
+		cfg.SealTimeout = DefaultConfig.SealTimeout
+	}
+	if cfg.TraceTimeout <= 0 {
+		cfg.TraceTimeout = DefaultConfig.TraceTimeout
+	}
+	if cfg.MaxConcurrentSeals <= 0 {
+		cfg.MaxConcurrentSeals = DefaultConfig.MaxConcurrentSeals
+	}
+	if cfg.SealedBlocks <= 0 {
+		cfg.SealedBlocks = DefaultConfig.SealedBlocks
+	}
+	if cfg.GasCeil == 0 {
+		cfg.GasCeil = ethconfig.Defaults.Miner.GasCeil
+	}
+	return cfg
+}
+
+// checkRequest enforces the configured limits on a seal request.
+func (s *Sealer) checkRequest(p *BlockParameters, txns []*types.Transaction, opts *SealOptions) error {
+	count := len(txns)
+	if opts != nil {
+		for _, bundle := range opts.Bundles {
+			count += len(bundle.Transactions)
+		}
+	}
+	if count > s.config.MaxTxsPerRequest {
+		return fmt.Errorf("too many transactions: %d, the maximum is %d", count, s.config.MaxTxsPerRequest)
+	}
+	if len(s.config.AllowedCoinbases) == 0 {
+		return nil
+	}
+	for _, coinbase := range s.config.AllowedCoinbases {
+		if coinbase == p.Coinbase {
+			return nil
+		}
+	}
+	return fmt.Errorf("coinbase %s is not allowed", p.Coinbase.Hex())
+}
+
+// acquire waits for one of the concurrent seal slots, and returns the function
+// releasing it.
+func (s *Sealer) acquire(ctx context.Context) (func(), error) {
+	select {
+	case s.slots <- struct{}{}:
+		return func() { <-s.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
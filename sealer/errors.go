@@ -0,0 +1,53 @@
+package sealer
+
+import (
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// SealerError is an invalid seal request, with an error code telling the
+// caller which field to fix. The details are returned as error data.
+type SealerError struct {
+	code int
+	msg  string
+	err  error
+}
+
+func (e *SealerError) ErrorCode() int { return e.code }
+func (e *SealerError) Error() string {
+	if e.err == nil {
+		return e.msg
+	}
+	return e.msg + ": " + e.err.Error()
+}
+func (e *SealerError) ErrorData() interface{} {
+	if e.err == nil {
+		return nil
+	}
+	return struct {
+		Error string `json:"err"`
+	}{e.err.Error()}
+}
+
+// With returns a copy of the error with a new embedded custom data field.
+func (e *SealerError) With(err error) *SealerError {
+	return &SealerError{
+		code: e.code,
+		msg:  e.msg,
+		err:  err,
+	}
+}
+
+func (e *SealerError) Unwrap() error { return e.err }
+
+var (
+	_ rpc.Error     = new(SealerError)
+	_ rpc.DataError = new(SealerError)
+)
+
+var (
+	InvalidTimestamp   = &SealerError{code: -38100, msg: "Invalid timestamp"}
+	InvalidGasLimit    = &SealerError{code: -38101, msg: "Invalid gas limit"}
+	ExtraDataTooLong   = &SealerError{code: -38102, msg: "Extra data too long"}
+	InvalidWithdrawals = &SealerError{code: -38103, msg: "Invalid withdrawals"}
+	InvalidBeaconRoot  = &SealerError{code: -38104, msg: "Invalid parent beacon block root"}
+)
@@ -1,6 +1,7 @@
 package sealer
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 
@@ -14,7 +15,7 @@ type NonceAndBalance struct {
 	Balance hexutil.Big    `json:"balance"`
 }
 
-func (s *Sealer) GetBulkAccountNoncesAndBalances(accounts []common.Address, blockHash common.Hash) ([]NonceAndBalance, error) {
+func (s *Sealer) GetBulkAccountNoncesAndBalances(ctx context.Context, accounts []common.Address, blockHash common.Hash) ([]NonceAndBalance, error) {
 	bl := s.chain.GetBlockByHash(blockHash)
 	if bl == nil {
 		return nil, fmt.Errorf("could not find block %s", blockHash.Hex())
@@ -30,6 +31,9 @@ func (s *Sealer) GetBulkAccountNoncesAndBalances(accounts []common.Address, bloc
 
 	nnb := make([]NonceAndBalance, len(accounts))
 	for i, a := range accounts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		a := a
 		nonce := state.GetNonce(a)
 		balance := state.GetBalance(a)
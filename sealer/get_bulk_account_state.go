@@ -0,0 +1,187 @@
+package sealer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// AccountStateRequest selects an account and the storage slots to read.
+type AccountStateRequest struct {
+	Address common.Address `json:"address"`
+	Slots   []common.Hash  `json:"slots"`
+}
+
+type AccountState struct {
+	Address      common.Address              `json:"address"`
+	Nonce        hexutil.Uint64              `json:"nonce"`
+	Balance      *hexutil.Big                `json:"balance"`
+	CodeHash     common.Hash                 `json:"codeHash"`
+	StorageHash  common.Hash                 `json:"storageHash"`
+	Storage      map[common.Hash]common.Hash `json:"storage"`
+	AccountProof []string                    `json:"accountProof,omitempty"`
+	StorageProof []ethapi.StorageResult      `json:"storageProof,omitempty"`
+}
+
+// GetBulkAccountState reads the accounts and storage slots requested at the
+// given block. The reads are spread over several workers, each with its own
+// view of the state backed by the snapshot layer when available. With
+// withProof set, the Merkle proofs of the accounts and slots are returned as
+// in eth_getProof.
+func (s *Sealer) GetBulkAccountState(ctx context.Context, requests []AccountStateRequest, blockNrOrHash rpc.BlockNumberOrHash, withProof *bool) ([]AccountState, error) {
+	reads := len(requests)
+	for _, req := range requests {
+		reads += len(req.Slots)
+	}
+	if reads > s.config.MaxStateReads {
+		return nil, fmt.Errorf("too many accounts and slots: %d, the maximum is %d", reads, s.config.MaxStateReads)
+	}
+	header, err := s.headerByNumberOrHash(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		prove   = withProof != nil && *withProof
+		results = make([]AccountState, len(requests))
+		errs    = make([]error, len(requests))
+		next    = make(chan int)
+		workers = runtime.NumCPU()
+		wg      sync.WaitGroup
+	)
+	if workers > len(requests) {
+		workers = len(requests)
+	}
+	for w := 0; w < workers; w++ {
+		statedb, err := s.chain.StateAt(header.Root)
+		if err != nil {
+			close(next)
+			wg.Wait()
+			return nil, fmt.Errorf("could not find root state of block %s: %s", header.Hash().Hex(), err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if ctx.Err() != nil {
+					continue
+				}
+				results[i], errs[i] = readAccountState(statedb, header.Root, requests[i], prove)
+			}
+		}()
+	}
+	for i := range requests {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("could not read account %s: %w", requests[i].Address.Hex(), err)
+		}
+	}
+	return results, nil
+}
+
+// readAccountState reads a single account from statedb, which must not be
+// shared with other goroutines.
+func readAccountState(statedb *state.StateDB, root common.Hash, req AccountStateRequest, prove bool) (AccountState, error) {
+	res := AccountState{
+		Address:     req.Address,
+		Nonce:       hexutil.Uint64(statedb.GetNonce(req.Address)),
+		Balance:     (*hexutil.Big)(statedb.GetBalance(req.Address)),
+		CodeHash:    statedb.GetCodeHash(req.Address),
+		StorageHash: statedb.GetStorageRoot(req.Address),
+		Storage:     make(map[common.Hash]common.Hash, len(req.Slots)),
+	}
+	for _, slot := range req.Slots {
+		res.Storage[slot] = statedb.GetState(req.Address, slot)
+	}
+	if err := statedb.Error(); err != nil {
+		return AccountState{}, err
+	}
+	if !prove {
+		return res, nil
+	}
+	tr, err := trie.NewStateTrie(trie.StateTrieID(root), statedb.Database().TrieDB())
+	if err != nil {
+		return AccountState{}, err
+	}
+	var accountProof ethapi.ProofList
+	if err := tr.Prove(crypto.Keccak256(req.Address.Bytes()), &accountProof); err != nil {
+		return AccountState{}, err
+	}
+	res.AccountProof = accountProof
+
+	var storageTrie state.Trie
+	if res.StorageHash != types.EmptyRootHash && res.StorageHash != (common.Hash{}) {
+		id := trie.StorageTrieID(root, crypto.Keccak256Hash(req.Address.Bytes()), res.StorageHash)
+		if storageTrie, err = trie.NewStateTrie(id, statedb.Database().TrieDB()); err != nil {
+			return AccountState{}, err
+		}
+	}
+	res.StorageProof = make([]ethapi.StorageResult, len(req.Slots))
+	for i, slot := range req.Slots {
+		value := (*hexutil.Big)(res.Storage[slot].Big())
+		if storageTrie == nil {
+			res.StorageProof[i] = ethapi.StorageResult{Key: slot.Hex(), Value: value, Proof: []string{}}
+			continue
+		}
+		var proof ethapi.ProofList
+		if err := storageTrie.Prove(crypto.Keccak256(slot.Bytes()), &proof); err != nil {
+			return AccountState{}, err
+		}
+		res.StorageProof[i] = ethapi.StorageResult{Key: slot.Hex(), Value: value, Proof: proof}
+	}
+	return res, nil
+}
+
+// headerByNumberOrHash resolves a block number, tag or hash to a header of
+// the local chain. The pending block resolves to the latest one.
+func (s *Sealer) headerByNumberOrHash(blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		header := s.chain.GetHeaderByHash(hash)
+		if header == nil {
+			return nil, fmt.Errorf("could not find block %s", hash.Hex())
+		}
+		if blockNrOrHash.RequireCanonical && s.chain.GetCanonicalHash(header.Number.Uint64()) != hash {
+			return nil, fmt.Errorf("block %s is not canonical", hash.Hex())
+		}
+		return header, nil
+	}
+	number, ok := blockNrOrHash.Number()
+	if !ok {
+		return nil, errors.New("invalid arguments; neither block nor hash specified")
+	}
+	var header *types.Header
+	switch number {
+	case rpc.PendingBlockNumber, rpc.LatestBlockNumber:
+		header = s.chain.CurrentBlock()
+	case rpc.FinalizedBlockNumber:
+		header = s.chain.CurrentFinalBlock()
+	case rpc.SafeBlockNumber:
+		header = s.chain.CurrentSafeBlock()
+	case rpc.EarliestBlockNumber:
+		header = s.chain.GetHeaderByNumber(0)
+	default:
+		header = s.chain.GetHeaderByNumber(uint64(number))
+	}
+	if header == nil {
+		return nil, fmt.Errorf("could not find block %d", number)
+	}
+	return header, nil
+}
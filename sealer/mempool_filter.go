@@ -0,0 +1,177 @@
+package sealer
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Reasons a mempool transaction is rejected by a MempoolFilter.
+const (
+	rejectDeniedSender        = "denied sender"
+	rejectSenderNotAllowed    = "sender not allowed"
+	rejectDeniedRecipient     = "denied recipient"
+	rejectRecipientNotAllowed = "recipient not allowed"
+	rejectTipTooLow           = "tip too low"
+	rejectGasTooHigh          = "gas too high"
+	rejectBlobTransaction     = "blob transaction"
+	rejectNonceConflict       = "nonce conflict with caller transaction"
+	rejectNonceGap            = "nonce gap after rejected transaction"
+	rejectEvicted             = "evicted from the pool"
+)
+
+// MempoolFilter restricts the mempool transactions used to fill the block.
+// Allow lists are ignored when empty.
+type MempoolFilter struct {
+	DenySenders     []common.Address `json:"denySenders,omitempty"`
+	AllowSenders    []common.Address `json:"allowSenders,omitempty"`
+	DenyRecipients  []common.Address `json:"denyRecipients,omitempty"`
+	AllowRecipients []common.Address `json:"allowRecipients,omitempty"`
+
+	MinTip  *hexutil.Big    `json:"minTip,omitempty"` // Minimum effective tip per gas
+	MaxGas  *hexutil.Uint64 `json:"maxGas,omitempty"` // Maximum gas limit per transaction
+	NoBlobs bool            `json:"excludeBlobTxs,omitempty"`
+
+	// ExcludeConflicts drops the mempool transactions with the same sender
+	// and nonce as a caller transaction.
+	ExcludeConflicts bool `json:"excludeConflicts,omitempty"`
+}
+
+func addressSet(addrs []common.Address) map[common.Address]bool {
+	set := make(map[common.Address]bool, len(addrs))
+	for _, addr := range addrs {
+		set[addr] = true
+	}
+	return set
+}
+
+// senderNonce identifies a transaction slot of an account.
+type senderNonce struct {
+	sender common.Address
+	nonce  uint64
+}
+
+// apply filters the pending transactions in place, and returns the number of
+// rejected transactions by reason. The nonce ordered list of an account is cut
+// at its first rejected transaction, as the following ones could not execute.
+// Caller transactions are taken from txns and bundles.
+func (f *MempoolFilter) apply(s *Sealer, pending map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int, txns []*types.Transaction, bundles []*Bundle) map[string]int {
+	var (
+		rejected        = make(map[string]int)
+		denySenders     = addressSet(f.DenySenders)
+		allowSenders    = addressSet(f.AllowSenders)
+		denyRecipients  = addressSet(f.DenyRecipients)
+		allowRecipients = addressSet(f.AllowRecipients)
+		resolve         = len(denyRecipients) > 0 || len(allowRecipients) > 0 || f.ExcludeConflicts
+		conflicts       = make(map[senderNonce]bool)
+	)
+	if f.ExcludeConflicts {
+		callerTxs := append([]*types.Transaction{}, txns...)
+		for _, bundle := range bundles {
+			callerTxs = append(callerTxs, bundle.Transactions...)
+		}
+		for _, tx := range callerTxs {
+			if from, err := types.Sender(s.signer, tx); err == nil {
+				conflicts[senderNonce{from, tx.Nonce()}] = true
+			}
+		}
+	}
+	for from, list := range pending {
+		var reason string
+		switch {
+		case denySenders[from]:
+			reason = rejectDeniedSender
+		case len(allowSenders) > 0 && !allowSenders[from]:
+			reason = rejectSenderNotAllowed
+		}
+		if reason != "" {
+			rejected[reason] += len(list)
+			delete(pending, from)
+			continue
+		}
+		kept := make([]*txpool.LazyTransaction, 0, len(list))
+		for i, ltx := range list {
+			var tx *types.Transaction
+			if resolve {
+				if tx = ltx.Resolve(); tx == nil {
+					reason = rejectEvicted
+				}
+			}
+			if reason == "" {
+				reason = f.reject(ltx, tx, baseFee, denyRecipients, allowRecipients)
+			}
+			if reason == "" && f.ExcludeConflicts && conflicts[senderNonce{from, tx.Nonce()}] {
+				// The caller transaction takes the place of this one, so
+				// the following ones remain executable.
+				rejected[rejectNonceConflict]++
+				continue
+			}
+			if reason != "" {
+				rejected[reason]++
+				rejected[rejectNonceGap] += len(list) - i - 1
+				break
+			}
+			kept = append(kept, ltx)
+		}
+		if len(kept) == 0 {
+			delete(pending, from)
+		} else {
+			pending[from] = kept
+		}
+	}
+	// Only report the reasons that did reject a transaction.
+	for reason, count := range rejected {
+		if count == 0 {
+			delete(rejected, reason)
+		}
+	}
+	return rejected
+}
+
+// reject returns why a single transaction is rejected, or an empty string if
+// it passes the filter. The resolved transaction is only given if needed.
+func (f *MempoolFilter) reject(ltx *txpool.LazyTransaction, tx *types.Transaction, baseFee *big.Int, denyRecipients, allowRecipients map[common.Address]bool) string {
+	if f.NoBlobs && ltx.BlobGas > 0 {
+		return rejectBlobTransaction
+	}
+	if f.MaxGas != nil && ltx.Gas > uint64(*f.MaxGas) {
+		return rejectGasTooHigh
+	}
+	if f.MinTip != nil {
+		tip := ltx.GasTipCap
+		if baseFee != nil {
+			tip = math.BigMin(ltx.GasTipCap, new(big.Int).Sub(ltx.GasFeeCap, baseFee))
+		}
+		if tip.Cmp(f.MinTip.ToInt()) < 0 {
+			return rejectTipTooLow
+		}
+	}
+	if tx != nil && (len(denyRecipients) > 0 || len(allowRecipients) > 0) {
+		// Contract creations have no recipient to allow.
+		to := tx.To()
+		switch {
+		case to != nil && denyRecipients[*to]:
+			return rejectDeniedRecipient
+		case len(allowRecipients) > 0 && (to == nil || !allowRecipients[*to]):
+			return rejectRecipientNotAllowed
+		}
+	}
+	return ""
+}
+
+// pending returns the mempool transactions to fill the block with, if any,
+// after applying the filter of the request. The rejections are kept in env.
+func (s *Sealer) pending(env *environment, fillWithMempool bool, txns []*types.Transaction, bundles []*Bundle, opts *SealOptions) map[common.Address][]*txpool.LazyTransaction {
+	if !fillWithMempool {
+		return map[common.Address][]*txpool.LazyTransaction{}
+	}
+	mempool := s.txpool.Pending(true)
+	if opts != nil && opts.MempoolFilter != nil {
+		env.rejected = opts.MempoolFilter.apply(s, mempool, env.header.BaseFee, txns, bundles)
+	}
+	return mempool
+}
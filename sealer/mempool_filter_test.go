@@ -0,0 +1,117 @@
+package sealer
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestMempoolFilter(t *testing.T) {
+	var (
+		signer = types.LatestSignerForChainID(big.NewInt(1))
+		s      = &Sealer{signer: signer}
+		sender = crypto.PubkeyToAddress(testKey.PublicKey)
+		other  = common.Address{0xaa}
+		maxGas = hexutil.Uint64(21000)
+		lowGas = hexutil.Uint64(20999)
+	)
+	lazy := func(txs ...*types.Transaction) []*txpool.LazyTransaction {
+		list := make([]*txpool.LazyTransaction, len(txs))
+		for i, tx := range txs {
+			list[i] = &txpool.LazyTransaction{
+				Hash:      tx.Hash(),
+				Tx:        tx,
+				GasFeeCap: tx.GasFeeCap(),
+				GasTipCap: tx.GasTipCap(),
+				Gas:       tx.Gas(),
+				BlobGas:   tx.BlobGas(),
+			}
+		}
+		return list
+	}
+	tests := []struct {
+		name     string
+		filter   MempoolFilter
+		txns     []*types.Transaction
+		blob     bool // Whether the second pending transaction carries a blob
+		kept     int
+		rejected map[string]int
+	}{
+		{
+			name:     "deny sender",
+			filter:   MempoolFilter{DenySenders: []common.Address{sender}},
+			rejected: map[string]int{rejectDeniedSender: 3},
+		},
+		{
+			name:     "sender not allowed",
+			filter:   MempoolFilter{AllowSenders: []common.Address{other}},
+			rejected: map[string]int{rejectSenderNotAllowed: 3},
+		},
+		{
+			name:     "deny recipient",
+			filter:   MempoolFilter{DenyRecipients: []common.Address{{}}},
+			rejected: map[string]int{rejectDeniedRecipient: 1, rejectNonceGap: 2},
+		},
+		{
+			name:     "recipient not allowed",
+			filter:   MempoolFilter{AllowRecipients: []common.Address{other}},
+			rejected: map[string]int{rejectRecipientNotAllowed: 1, rejectNonceGap: 2},
+		},
+		{
+			name:     "tip too low",
+			filter:   MempoolFilter{MinTip: (*hexutil.Big)(big.NewInt(2))},
+			rejected: map[string]int{rejectTipTooLow: 1, rejectNonceGap: 2},
+		},
+		{
+			name:   "gas within limit",
+			filter: MempoolFilter{MaxGas: &maxGas, NoBlobs: true},
+			kept:   3,
+		},
+		{
+			name:     "gas too high",
+			filter:   MempoolFilter{MaxGas: &lowGas},
+			rejected: map[string]int{rejectGasTooHigh: 1, rejectNonceGap: 2},
+		},
+		{
+			name:     "blob transaction",
+			filter:   MempoolFilter{NoBlobs: true},
+			blob:     true,
+			kept:     1,
+			rejected: map[string]int{rejectBlobTransaction: 1, rejectNonceGap: 1},
+		},
+		{
+			name:     "nonce conflict",
+			filter:   MempoolFilter{ExcludeConflicts: true},
+			txns:     []*types.Transaction{signedTx(t, signer, 1)},
+			kept:     2,
+			rejected: map[string]int{rejectNonceConflict: 1},
+		},
+	}
+	for _, test := range tests {
+		pending := map[common.Address][]*txpool.LazyTransaction{
+			sender: lazy(signedTx(t, signer, 0), signedTx(t, signer, 1), signedTx(t, signer, 2)),
+		}
+		if test.blob {
+			pending[sender][1].BlobGas = params.BlobTxBlobGasPerBlob
+		}
+		rejected := test.filter.apply(s, pending, nil, test.txns, nil)
+		if have := len(pending[sender]); have != test.kept {
+			t.Errorf("%s: wrong number of kept transactions: have %d, want %d", test.name, have, test.kept)
+		}
+		if len(rejected) != len(test.rejected) {
+			t.Errorf("%s: wrong rejections: have %v, want %v", test.name, rejected, test.rejected)
+			continue
+		}
+		for reason, count := range test.rejected {
+			if rejected[reason] != count {
+				t.Errorf("%s: wrong rejections: have %v, want %v", test.name, rejected, test.rejected)
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package sealer
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	sealTimer      = metrics.NewRegisteredTimer("sealer/seal", nil)
+	stateLoadTimer = metrics.NewRegisteredTimer("sealer/phase/stateload", nil)
+	executionTimer = metrics.NewRegisteredTimer("sealer/phase/execution", nil)
+	finalizeTimer  = metrics.NewRegisteredTimer("sealer/phase/finalize", nil)
+	traceTimer     = metrics.NewRegisteredTimer("sealer/phase/trace", nil)
+
+	// State reads during execution, the prefetcher effectiveness itself is
+	// published under trie/prefetch/sealer.
+	accountReadTimer         = metrics.NewRegisteredTimer("sealer/account/reads", nil)
+	storageReadTimer         = metrics.NewRegisteredTimer("sealer/storage/reads", nil)
+	snapshotAccountReadTimer = metrics.NewRegisteredTimer("sealer/snapshot/account/reads", nil)
+	snapshotStorageReadTimer = metrics.NewRegisteredTimer("sealer/snapshot/storage/reads", nil)
+
+	mempoolConsideredMeter = metrics.NewRegisteredMeter("sealer/mempool/considered", nil)
+	includedMeter          = metrics.NewRegisteredMeter("sealer/txs/included", nil)
+	partialMeter           = metrics.NewRegisteredMeter("sealer/partial", nil)
+	blockTxsHistogram      = metrics.NewRegisteredHistogram("sealer/block/txs", nil, metrics.NewExpDecaySample(1028, 0.015))
+)
+
+// SealTimings is the time spent in each phase of a seal request. It is encoded
+// in milliseconds.
+type SealTimings struct {
+	StateLoad time.Duration
+	Execution time.Duration
+	Trace     time.Duration // Part of the execution spent tracing
+	Finalize  time.Duration
+	Total     time.Duration
+}
+
+// sealTimingsJSON is the encoding of SealTimings.
+type sealTimingsJSON struct {
+	StateLoad hexutil.Uint64 `json:"stateLoad"`
+	Execution hexutil.Uint64 `json:"execution"`
+	Trace     hexutil.Uint64 `json:"trace"`
+	Finalize  hexutil.Uint64 `json:"finalize"`
+	Total     hexutil.Uint64 `json:"total"`
+}
+
+func (t SealTimings) MarshalJSON() ([]byte, error) {
+	ms := func(d time.Duration) hexutil.Uint64 { return hexutil.Uint64(d.Milliseconds()) }
+	return json.Marshal(sealTimingsJSON{
+		StateLoad: ms(t.StateLoad),
+		Execution: ms(t.Execution),
+		Trace:     ms(t.Trace),
+		Finalize:  ms(t.Finalize),
+		Total:     ms(t.Total),
+	})
+}
+
+func (t *SealTimings) UnmarshalJSON(input []byte) error {
+	var dec sealTimingsJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	d := func(ms hexutil.Uint64) time.Duration { return time.Duration(ms) * time.Millisecond }
+	*t = SealTimings{
+		StateLoad: d(dec.StateLoad),
+		Execution: d(dec.Execution),
+		Trace:     d(dec.Trace),
+		Finalize:  d(dec.Finalize),
+		Total:     d(dec.Total),
+	}
+	return nil
+}
+
+// exclusionCategories maps the errors excluding a transaction to the name of
+// their counter. Error messages carry the transaction details, so they cannot
+// be used as metric names.
+var exclusionCategories = []struct {
+	err  error
+	name string
+}{
+	{core.ErrNonceTooLow, "noncetoolow"},
+	{core.ErrNonceTooHigh, "noncetoohigh"},
+	{core.ErrInsufficientFunds, "insufficientfunds"},
+	{core.ErrInsufficientFundsForTransfer, "insufficientfunds"},
+	{core.ErrGasLimitReached, "gaslimit"},
+	{core.ErrIntrinsicGas, "intrinsicgas"},
+	{core.ErrFeeCapTooLow, "feecaptoolow"},
+	{core.ErrSenderNoEOA, "sendernoeoa"},
+	{vm.ErrExecutionReverted, "reverted"},
+	{vm.ErrOutOfGas, "outofgas"},
+}
+
+// exclusionCategory returns the name of the counter of transactions excluded
+// by err.
+func exclusionCategory(err error) string {
+	for _, category := range exclusionCategories {
+		if errors.Is(err, category.err) {
+			return category.name
+		}
+	}
+	var revert rpc.DataError
+	if errors.As(err, &revert) {
+		// Reverts come with their decoded reason.
+		return "reverted"
+	}
+	return "other"
+}
+
+// markSealed records the metrics of a block sealed from env.
+func markSealed(env *environment) {
+	markExcluded(env.excluded)
+	markStateReads(env.state)
+	mempoolConsideredMeter.Mark(int64(env.considered))
+	includedMeter.Mark(int64(len(env.txs)))
+	blockTxsHistogram.Update(int64(len(env.txs)))
+	if env.interrupted != commitInterruptNone {
+		partialMeter.Mark(1)
+	}
+	stateLoadTimer.Update(env.timings.StateLoad)
+	executionTimer.Update(env.timings.Execution)
+	traceTimer.Update(env.timings.Trace)
+	finalizeTimer.Update(env.timings.Finalize)
+	sealTimer.UpdateSince(env.started)
+}
+
+// markExcluded counts the excluded transactions of a sealed block by category.
+func markExcluded(excluded []ExcludedTransaction) {
+	for _, tx := range excluded {
+		metrics.GetOrRegisterCounter("sealer/excluded/"+tx.category, nil).Inc(1)
+	}
+}
+
+// markStateReads records the time spent reading the state while filling a
+// block.
+func markStateReads(statedb *state.StateDB) {
+	accountReadTimer.Update(statedb.AccountReads)
+	storageReadTimer.Update(statedb.StorageReads)
+	snapshotAccountReadTimer.Update(statedb.SnapshotAccountReads)
+	snapshotStorageReadTimer.Update(statedb.SnapshotStorageReads)
+}
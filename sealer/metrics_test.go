@@ -0,0 +1,48 @@
+package sealer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+)
+
+func TestExclusionCategory(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("%w: address 0x01, tx: 0 state: 1", core.ErrNonceTooLow), "noncetoolow"},
+		{ethapi.NewRevertError(&core.ExecutionResult{Err: vm.ErrExecutionReverted}), "reverted"},
+		{vm.ErrOutOfGas, "outofgas"},
+		{errors.New("transaction type not supported"), "other"},
+	}
+	for _, test := range tests {
+		if have := exclusionCategory(test.err); have != test.want {
+			t.Errorf("%v: wrong category: have %s, want %s", test.err, have, test.want)
+		}
+	}
+}
+
+func TestSealTimingsJSON(t *testing.T) {
+	timings := SealTimings{StateLoad: 3 * time.Millisecond, Execution: 1500 * time.Microsecond, Total: time.Second}
+	enc, err := json.Marshal(&timings)
+	if err != nil {
+		t.Fatalf("failed to encode timings: %v", err)
+	}
+	if want := `{"stateLoad":"0x3","execution":"0x1","trace":"0x0","finalize":"0x0","total":"0x3e8"}`; string(enc) != want {
+		t.Fatalf("wrong encoding: have %s, want %s", enc, want)
+	}
+	var dec SealTimings
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatalf("failed to decode timings: %v", err)
+	}
+	if dec.StateLoad != timings.StateLoad || dec.Execution != time.Millisecond || dec.Total != timings.Total {
+		t.Fatalf("wrong decoded timings: %+v", dec)
+	}
+}
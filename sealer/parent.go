@@ -0,0 +1,125 @@
+package sealer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// speculativeChain is the chain as seen by a block sealed on top of a parent
+// the node has not imported. Headers missing from the local chain are looked
+// up in the sealed blocks and in the parent header supplied by the caller.
+type speculativeChain struct {
+	*core.BlockChain
+	sealer *Sealer
+	parent *types.Header // Parent header supplied by the caller, if any
+}
+
+func (c *speculativeChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := c.BlockChain.GetHeader(hash, number); header != nil {
+		return header
+	}
+	if c.parent != nil && c.parent.Hash() == hash {
+		return c.parent
+	}
+	if sealed, ok := c.sealer.sealed.Get(hash); ok && sealed.block.NumberU64() == number {
+		return sealed.block.Header()
+	}
+	return nil
+}
+
+// GetTd returns the total difficulty of a block. Blocks that were not imported
+// are post-merge, their total difficulty is the one of their parent.
+func (c *speculativeChain) GetTd(hash common.Hash, number uint64) *big.Int {
+	if td := c.BlockChain.GetTd(hash, number); td != nil {
+		return td
+	}
+	header := c.GetHeader(hash, number)
+	if header == nil || number == 0 || header.Difficulty == nil || header.Difficulty.Sign() != 0 {
+		return nil
+	}
+	return c.GetTd(header.ParentHash, number-1)
+}
+
+// resolveParent returns the header and a private copy of the state of the
+// block to seal on top of. The parent is, in order:
+//   - the header supplied in opts, on top of the state of its own parent with
+//     the state diff of opts applied,
+//   - the block of the local chain requested in p, the head if none,
+//   - a block sealed earlier and still cached, to chain speculative blocks.
+func (s *Sealer) resolveParent(p *BlockParameters, opts *SealOptions) (*types.Header, *state.StateDB, error) {
+	if opts != nil && opts.ParentHeader != nil {
+		parent := opts.ParentHeader
+		if p.ParentHash != (common.Hash{}) && p.ParentHash != parent.Hash() {
+			return nil, nil, fmt.Errorf("parent hash %s does not match the parent header %s", p.ParentHash.Hex(), parent.Hash().Hex())
+		}
+		if parent.Number == nil || parent.Number.Sign() == 0 {
+			return nil, nil, errors.New("invalid parent header number")
+		}
+		_, statedb, err := s.parentByHash(parent.ParentHash, parent.Number.Uint64()-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		// The fields of the forks, such as the base fee and the blob gas, are
+		// derived from the parent: check it against its own parent first.
+		if err := s.engine.VerifyHeader(&speculativeChain{BlockChain: s.chain, sealer: s}, parent); err != nil {
+			return nil, nil, fmt.Errorf("invalid parent header: %w", err)
+		}
+		if err := opts.ParentStateDiff.Apply(statedb); err != nil {
+			return nil, nil, fmt.Errorf("could not apply the parent state diff: %w", err)
+		}
+		// Settle the diff as the post-state of the parent block, so that it is
+		// not mistaken for changes made by the sealed transactions, and check
+		// it is the state the parent header commits to.
+		if root := statedb.IntermediateRoot(s.chainConfig.IsEIP158(parent.Number)); root != parent.Root {
+			return nil, nil, fmt.Errorf("parent state root mismatch: have %s, want %s", root.Hex(), parent.Root.Hex())
+		}
+		return parent, statedb, nil
+	}
+	if opts != nil && opts.ParentStateDiff != nil {
+		return nil, nil, errors.New("parent state diff given without a parent header")
+	}
+	if p.ParentHash == (common.Hash{}) {
+		parent := s.chain.CurrentBlock()
+		statedb, err := s.chain.StateAt(parent.Root)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not find root state of head block %s: %s", parent.Hash().Hex(), err)
+		}
+		return parent, statedb, nil
+	}
+	return s.parentByHash(p.ParentHash, 0)
+}
+
+// parentByHash returns the header and a private copy of the state of a block
+// of the local chain, or of a cached sealed block. The number is only used to
+// validate the block if not zero.
+func (s *Sealer) parentByHash(hash common.Hash, number uint64) (*types.Header, *state.StateDB, error) {
+	if parent := s.chain.GetHeaderByHash(hash); parent != nil {
+		if number != 0 && parent.Number.Uint64() != number {
+			return nil, nil, fmt.Errorf("block %s has number %d, want %d", hash.Hex(), parent.Number, number)
+		}
+		statedb, err := s.chain.StateAt(parent.Root)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not find root state of parent block %s: %s", hash.Hex(), err)
+		}
+		return parent, statedb, nil
+	}
+	sealed, ok := s.sealed.Get(hash)
+	if !ok {
+		return nil, nil, fmt.Errorf("could not find parent block %s", hash.Hex())
+	}
+	if number != 0 && sealed.block.NumberU64() != number {
+		return nil, nil, fmt.Errorf("block %s has number %d, want %d", hash.Hex(), sealed.block.NumberU64(), number)
+	}
+	return sealed.block.Header(), sealed.state.Copy(), nil
+}
+
+// imported reports whether the node has imported the parent block.
+func (s *Sealer) imported(parent *types.Header) bool {
+	return s.chain.GetHeader(parent.Hash(), parent.Number.Uint64()) != nil
+}
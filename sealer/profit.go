@@ -0,0 +1,41 @@
+package sealer
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// TransactionProfit is the contribution of an included transaction to the
+// coinbase balance, split into the priority fee paid for its gas and the
+// direct transfers it made to the coinbase.
+type TransactionProfit struct {
+	Hash          common.Hash  `json:"hash"`
+	Profit        *hexutil.Big `json:"profit"`
+	PriorityFee   *hexutil.Big `json:"priorityFee"`
+	DirectPayment *hexutil.Big `json:"directPayment"`
+}
+
+// coinbaseBalance returns a copy of the current balance of the coinbase.
+func (env *environment) coinbaseBalance() *big.Int {
+	return new(big.Int).Set(env.state.GetBalance(env.coinbase))
+}
+
+// coinbaseDelta returns the change of the coinbase balance since before.
+func (env *environment) coinbaseDelta(before *big.Int) *big.Int {
+	return new(big.Int).Sub(env.state.GetBalance(env.coinbase), before)
+}
+
+// profit returns the coinbase balance delta between the parent state and the
+// current state of env, and its split into priority fees and direct payments.
+func (env *environment) profit() (profit, fees, direct *hexutil.Big) {
+	delta := env.coinbaseDelta(env.startBalance)
+	return (*hexutil.Big)(delta), (*hexutil.Big)(new(big.Int).Set(env.reward)), (*hexutil.Big)(new(big.Int).Sub(delta, env.reward))
+}
+
+// setProfit fills in the profit of the sealed block from env.
+func (sb *SealedBlock) setProfit(env *environment) {
+	sb.Profit, sb.PriorityFees, sb.DirectPayments = env.profit()
+	sb.TransactionProfits = env.profits
+}
@@ -1,14 +1,26 @@
 package sealer
 
 import (
+	"fmt"
+
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
 func Register(stack *node.Node, backend *eth.Ethereum, cfg *SealerConfig) error {
+	_, err := register(stack, backend, cfg)
+	return err
+}
 
-	sealerService := newSealer(backend)
+// register creates the sealer and registers its API on the node, and returns
+// the registered service.
+func register(stack *node.Node, backend *eth.Ethereum, cfg *SealerConfig) (*Sealer, error) {
+	if len(cfg.ExtraData) > int(params.MaximumExtraDataSize) {
+		return nil, fmt.Errorf("extra data too long: %d bytes, the maximum is %d", len(cfg.ExtraData), params.MaximumExtraDataSize)
+	}
+	sealerService := newSealer(backend, *cfg)
 
 	stack.RegisterAPIs([]rpc.API{
 		{
@@ -19,5 +31,5 @@ func Register(stack *node.Node, backend *eth.Ethereum, cfg *SealerConfig) error
 			Authenticated: !cfg.Insecure,
 		},
 	})
-	return nil
+	return sealerService, nil
 }
@@ -1,15 +1,20 @@
 package sealer
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/beacon/engine"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txpool"
@@ -17,13 +22,16 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/eth/tracers"
-	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/params"
 )
 
 type ExcludedTransaction struct {
-	Hash   common.Hash `json:"hash"`
-	Reason string      `json:"reason"`
+	Hash       common.Hash `json:"hash"`
+	Reason     string      `json:"reason"`
+	RevertData string      `json:"revertData,omitempty"`
+
+	category string // Name of the exclusion counter
 }
 
 type SealedBlock struct {
@@ -31,17 +39,171 @@ type SealedBlock struct {
 	ExcludedTransactions []ExcludedTransaction            `json:"excludedTxns"`
 	Receipts             []*types.Receipt                 `json:"receipts"`
 	Traces               []json.RawMessage                `json:"traces,omitempty"`
+	TxTraces             map[common.Hash]json.RawMessage  `json:"txTraces,omitempty"`
 	Profit               *hexutil.Big                     `json:"profit"`
+	PriorityFees         *hexutil.Big                     `json:"priorityFees"`
+	DirectPayments       *hexutil.Big                     `json:"directPayments"`
+	TransactionProfits   []TransactionProfit              `json:"txProfits"`
+	Strategy             string                           `json:"strategy,omitempty"`
+	Candidates           []CandidateProfit                `json:"candidates,omitempty"`
+	Partial              bool                             `json:"partial,omitempty"`
+	InterruptReason      string                           `json:"interruptReason,omitempty"`
+	MempoolRejections    map[string]int                   `json:"mempoolRejections,omitempty"`
+	Timings              *SealTimings                     `json:"timings,omitempty"`
 }
 
+// BlockParameters are the header fields of the block to seal. Without a gas
+// limit, the gas limit moves from the parent's toward the gas target, or the
+// configured gas ceiling.
 type BlockParameters struct {
 	ParentHash  common.Hash         `json:"parent"`
 	Coinbase    common.Address      `json:"coinbase"`
 	Timestamp   uint64              `json:"timestamp"`
 	GasLimit    uint64              `json:"gasLimit"`
+	GasTarget   uint64              `json:"gasLimitTarget,omitempty"`
 	Random      common.Hash         `json:"random"`
 	Extra       []byte              `json:"extraData"`
 	Withdrawals []*types.Withdrawal `json:"withdrawals"`
+	BeaconRoot  *common.Hash        `json:"parentBeaconBlockRoot"`
+}
+
+// SealOptions carries the optional arguments of SealBlock.
+type SealOptions struct {
+	// Bundles are included after the top transactions and before the
+	// mempool, each one all-or-nothing.
+	Bundles []*Bundle `json:"bundles,omitempty"`
+
+	// RevertingTxHashes lists the caller transactions, top of block or in a
+	// bundle, that may be included even if their execution reverts. Any
+	// other caller transaction that reverts is rolled back and excluded.
+	RevertingTxHashes []common.Hash `json:"revertingTxHashes,omitempty"`
+
+	// BlobSidecars are the blobs of the caller supplied blob transactions.
+	BlobSidecars []*BlobSidecar `json:"blobSidecars,omitempty"`
+
+	// TraceConfig selects the tracer run on every transaction, as in the
+	// debug API. The trace of each transaction, excluded ones up to their
+	// failure point included, is returned by hash.
+	TraceConfig *tracers.TraceConfig `json:"traceConfig,omitempty"`
+
+	// Deadline is the wall-clock time, in unix milliseconds, after which no
+	// more transactions are added. The block built so far is returned and
+	// flagged as partial.
+	Deadline *uint64 `json:"deadline,omitempty"`
+
+	// Strategies are the ordering strategies to build candidate blocks with,
+	// concurrently if more than one. The most profitable candidate is sealed.
+	Strategies []string `json:"strategies,omitempty"`
+
+	// MempoolFilter restricts the mempool transactions the block is filled
+	// with. The rejected ones are counted by reason.
+	MempoolFilter *MempoolFilter `json:"mempoolFilter,omitempty"`
+
+	// Timings requests the time spent in each phase of the request.
+	Timings bool `json:"timings,omitempty"`
+
+	// ParentHeader is the header of a block the node has not imported yet,
+	// to seal on top of. Its state is taken as the state of its own parent,
+	// imported or sealed earlier, with ParentStateDiff applied.
+	ParentHeader    *types.Header         `json:"parentHeader,omitempty"`
+	ParentStateDiff *ethapi.StateOverride `json:"parentStateDiff,omitempty"`
+}
+
+const (
+	commitInterruptNone int32 = iota
+	commitInterruptTimeout
+	commitInterruptDeadline
+	commitInterruptCanceled
+)
+
+// environment is the block under construction.
+type environment struct {
+	state       *state.StateDB
+	gasPool     *core.GasPool
+	header      *types.Header
+	coinbase    common.Address
+	rules       params.Rules
+	precompiles []common.Address
+	callers     map[common.Hash]bool // Transactions supplied by the caller
+	revertible  map[common.Hash]bool
+	tracer      *traceSpec    // Tracer to run on every transaction, nil if not tracing
+	interrupt   *atomic.Int32 // Signal to stop filling the block, shared by the candidates
+	interrupted int32         // Signal that stopped filling the block, if any
+
+	blobSidecars map[common.Hash]*types.BlobTxSidecar // Sidecars supplied by the caller
+	blobs        int
+	sidecars     []*types.BlobTxSidecar
+
+	txs          []*types.Transaction
+	receipts     []*types.Receipt
+	results      []*core.ExecutionResult
+	traces       []json.RawMessage
+	reward       *big.Int // Sum of the priority fees
+	startBalance *big.Int // Coinbase balance in the parent state
+	profits      []TransactionProfit
+	excluded     []ExcludedTransaction
+	rejected     map[string]int // Mempool transactions rejected by the filter, by reason
+
+	// txTraces holds the traces of included and excluded transactions.
+	txTraces map[common.Hash]json.RawMessage
+
+	chain       *speculativeChain // Chain resolving the ancestors of the block
+	parentState *state.StateDB    // State of the parent supplied by the caller, if any
+	started     time.Time         // Start of the request
+	timings     *SealTimings      // Time spent in each phase so far
+	withTimings bool              // Whether to return the timings
+	considered  int               // Mempool transactions taken from the stream
+}
+
+// copy returns a deep copy of the environment, sharing only the read-only
+// inputs of the request.
+func (env *environment) copy() *environment {
+	cpy := *env
+	cpy.state = env.state.Copy()
+	cpy.gasPool = new(core.GasPool).AddGas(env.gasPool.Gas())
+	cpy.header = types.CopyHeader(env.header)
+	cpy.sidecars = append([]*types.BlobTxSidecar{}, env.sidecars...)
+	cpy.txs = append([]*types.Transaction{}, env.txs...)
+	cpy.receipts = append([]*types.Receipt{}, env.receipts...)
+	cpy.results = append([]*core.ExecutionResult{}, env.results...)
+	cpy.traces = append([]json.RawMessage{}, env.traces...)
+	cpy.txTraces = make(map[common.Hash]json.RawMessage, len(env.txTraces))
+	for hash, trace := range env.txTraces {
+		cpy.txTraces[hash] = trace
+	}
+	cpy.reward = new(big.Int).Set(env.reward)
+	cpy.profits = append([]TransactionProfit{}, env.profits...)
+	cpy.excluded = append([]ExcludedTransaction{}, env.excluded...)
+	timings := *env.timings
+	cpy.timings = &timings
+	return &cpy
+}
+
+// include appends a successfully executed transaction to the block, profit
+// being the change of the coinbase balance caused by the transaction.
+func (env *environment) include(tx *types.Transaction, receipt *types.Receipt, result *core.ExecutionResult, traceBody json.RawMessage, profit *big.Int) {
+	reward := new(big.Int).SetUint64(receipt.GasUsed)
+	reward = reward.Mul(reward, tx.EffectiveGasTipValue(env.header.BaseFee))
+	env.reward = env.reward.Add(env.reward, reward)
+	env.profits = append(env.profits, TransactionProfit{
+		Hash:          tx.Hash(),
+		Profit:        (*hexutil.Big)(profit),
+		PriorityFee:   (*hexutil.Big)(reward),
+		DirectPayment: (*hexutil.Big)(new(big.Int).Sub(profit, reward)),
+	})
+	if sc := env.sidecarOf(tx); sc != nil {
+		env.sidecars = append(env.sidecars, sc)
+		*env.header.BlobGasUsed += receipt.BlobGasUsed
+		tx = tx.WithoutBlobTxSidecar()
+	}
+
+	env.txs = append(env.txs, tx)
+	env.receipts = append(env.receipts, receipt)
+	env.results = append(env.results, result)
+	if env.tracer != nil {
+		env.traces = append(env.traces, traceBody)
+		env.txTraces[tx.Hash()] = traceBody
+	}
 }
 
 type Sealer struct {
@@ -50,11 +212,16 @@ type Sealer struct {
 	chain       *core.BlockChain
 	engine      consensus.Engine
 	txpool      *txpool.TxPool
+	sealed      *lru.Cache[common.Hash, *sealedBlock]
+	config      SealerConfig
+	slots       chan struct{} // Seal requests in progress, bounded by the config
 }
 
 func newSealer(
 	backend *eth.Ethereum,
+	config SealerConfig,
 ) *Sealer {
+	config = config.sanitize()
 	chain := backend.BlockChain()
 	chainConfig := chain.Config()
 	signer := types.LatestSignerForChainID(chainConfig.ChainID)
@@ -64,185 +231,450 @@ func newSealer(
 		chain:       chain,
 		engine:      chain.Engine(),
 		txpool:      backend.TxPool(),
+		sealed:      lru.NewCache[common.Hash, *sealedBlock](config.SealedBlocks),
+		config:      config,
+		slots:       make(chan struct{}, config.MaxConcurrentSeals),
 	}
 }
 
-func (s *Sealer) SealBlock(p *BlockParameters, txns []*types.Transaction, fillWithMempool bool, trace bool) (*SealedBlock, error) {
-	var gp core.GasPool
+func (s *Sealer) SealBlock(ctx context.Context, p *BlockParameters, txns []*types.Transaction, fillWithMempool bool, trace bool, opts *SealOptions) (*SealedBlock, error) {
+	if err := s.checkRequest(p, txns, opts); err != nil {
+		return nil, err
+	}
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	env, err := s.prepareEnv(p, opts, nil)
+	if err != nil {
+		return nil, err
+	}
 
-	parent := s.chain.CurrentBlock()
-	if p.ParentHash != (common.Hash{}) {
-		parent = s.chain.GetHeaderByHash(p.ParentHash)
-		if parent == nil {
-			return nil, fmt.Errorf("could not find parent block %s", p.ParentHash.Hex())
+	if trace {
+		env.tracer = callTracerSpec(s.config.TraceTimeout)
+	}
+	if opts != nil && opts.TraceConfig != nil {
+		if env.tracer, err = newTraceSpec(opts.TraceConfig, s.config.TraceTimeout); err != nil {
+			env.state.StopPrefetcher()
+			return nil, err
 		}
 	}
 
+	bundles, strategies, err := env.applyOptions(txns, opts)
+	if err != nil {
+		env.state.StopPrefetcher()
+		return nil, err
+	}
+	var deadline *uint64
+	if opts != nil {
+		deadline = opts.Deadline
+	}
+	defer env.startInterrupts(ctx, s.config.SealTimeout, deadline)()
+
+	mempool := s.pending(env, fillWithMempool, txns, bundles, opts)
+
+	if len(strategies) == 1 {
+		defer env.stopPrefetcher()
+
+		stream := orderingStrategies[strategies[0]].newStream(s, env, txns, bundles, mempool)
+		s.fill(env, stream)
+		return s.finalize(env, p.Withdrawals)
+	}
+	return s.sealCandidates(env, strategies, p.Withdrawals, txns, bundles, mempool)
+}
+
+// applyOptions records the caller transactions and options in env, and returns
+// the bundles and the ordering strategies requested.
+func (env *environment) applyOptions(txns []*types.Transaction, opts *SealOptions) ([]*Bundle, []string, error) {
+	var (
+		bundles    []*Bundle
+		strategies = []string{defaultStrategy}
+		err        error
+	)
+	for _, tx := range txns {
+		env.callers[tx.Hash()] = true
+	}
+	if opts != nil {
+		bundles = opts.Bundles
+		for _, bundle := range bundles {
+			for _, tx := range bundle.Transactions {
+				env.callers[tx.Hash()] = true
+			}
+		}
+		for _, hash := range opts.RevertingTxHashes {
+			env.revertible[hash] = true
+		}
+		env.blobSidecars, err = blobSidecars(opts.BlobSidecars, txns, bundles)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(opts.Strategies) > 0 {
+			strategies = opts.Strategies
+		}
+		env.withTimings = opts.Timings
+	}
+	for _, name := range strategies {
+		if _, ok := orderingStrategies[name]; !ok {
+			return nil, nil, fmt.Errorf("unknown ordering strategy %q", name)
+		}
+	}
+	return bundles, strategies, nil
+}
+
+// prepareEnv creates the header of the block to seal on top of the parent
+// requested in p and opts, and the environment to fill it. The overrides, if
+// any, are applied to the parent state before the system calls of the block.
+func (s *Sealer) prepareEnv(p *BlockParameters, opts *SealOptions, overrides *ethapi.StateOverride) (*environment, error) {
+	started := time.Now()
+	parent, statedb, err := s.resolveParent(p, opts)
+	if err != nil {
+		return nil, err
+	}
+	stateLoad := time.Since(started)
+
+	if err := checkTimestamp(parent, p.Timestamp); err != nil {
+		return nil, err
+	}
+	gasLimit, err := s.gasLimit(p, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := &speculativeChain{BlockChain: s.chain, sealer: s}
+	var parentState *state.StateDB
+	if opts != nil && opts.ParentHeader != nil {
+		// Keep the state of a parent no one else knows of, to validate the
+		// sealed block later on.
+		chain.parent = opts.ParentHeader
+		parentState = statedb.Copy()
+	}
+
 	extraData := p.Extra
 	if extraData == nil {
-		extraData = []byte("Manifold")
+		extraData = s.config.ExtraData
 	}
 
 	header := &types.Header{
 		ParentHash: parent.Hash(),
 		Number:     big.NewInt(0).Add(parent.Number, common.Big1),
-		GasLimit:   p.GasLimit,
+		GasLimit:   gasLimit,
 		Time:       p.Timestamp,
 		Coinbase:   p.Coinbase,
 		Extra:      extraData,
 		MixDigest:  p.Random,
 	}
+	if err := s.checkHeaderFields(p, header); err != nil {
+		return nil, err
+	}
 
 	// Set baseFee and GasLimit if we are on an EIP-1559 chain
 	if s.chainConfig.IsLondon(header.Number) {
 		header.BaseFee = eip1559.CalcBaseFee(s.chainConfig, parent)
 	}
 
-	// Run the consensus preparation with the default or customized consensus engine.
-	err := s.engine.Prepare(s.chain, header)
-	if err != nil {
-		return nil, err
+	// Apply EIP-4844, EIP-4788.
+	if s.chainConfig.IsCancun(header.Number, header.Time) {
+		var excessBlobGas uint64
+		if s.chainConfig.IsCancun(parent.Number, parent.Time) {
+			excessBlobGas = eip4844.CalcExcessBlobGas(*parent.ExcessBlobGas, *parent.BlobGasUsed)
+		} else {
+			// For the first post-fork block, both parent.data_gas_used and parent.excess_data_gas are evaluated as 0
+			excessBlobGas = eip4844.CalcExcessBlobGas(0, 0)
+		}
+		header.BlobGasUsed = new(uint64)
+		header.ExcessBlobGas = &excessBlobGas
+		header.ParentBeaconRoot = p.BeaconRoot
 	}
 
-	receipts := []*types.Receipt{}
-	includedTxns := []*types.Transaction{}
-
-	state, err := s.chain.StateAt(parent.Root)
-	if err != nil {
-		return nil, fmt.Errorf("could not find root state of parent block %s: %s", p.ParentHash.Hex(), err)
+	// Run the consensus preparation with the default or customized consensus
+	// engine. It needs the total difficulty of the parent, which is unknown
+	// if it was not imported: only post-merge parents are supported then.
+	if s.imported(parent) {
+		if err := s.engine.Prepare(s.chain, header); err != nil {
+			return nil, err
+		}
+	} else if parent.Difficulty == nil || parent.Difficulty.Sign() != 0 {
+		return nil, fmt.Errorf("cannot seal on top of pre-merge block %s that was not imported", parent.Hash().Hex())
+	} else {
+		header.Difficulty = new(big.Int)
 	}
 
-	state.StartPrefetcher("sealer")
-	defer state.StopPrefetcher()
-
-	blockReward := big.NewInt(0)
-
-	sb := &SealedBlock{
-		ExcludedTransactions: []ExcludedTransaction{},
+	if err := overrides.Apply(statedb); err != nil {
+		return nil, err
 	}
+	statedb.StartPrefetcher("sealer")
 
-	gp.AddGas(header.GasLimit)
+	if header.ParentBeaconRoot != nil {
+		context := core.NewEVMBlockContext(header, chain, nil)
+		vmenv := vm.NewEVM(context, vm.TxContext{}, statedb, s.chainConfig, vm.Config{})
+		core.ProcessBeaconBlockRoot(*header.ParentBeaconRoot, vmenv, statedb)
+	}
 
-	mempool := map[common.Address][]*txpool.LazyTransaction{}
+	rules := s.chain.Config().Rules(header.Number, header.Difficulty.Cmp(common.Big0) == 0, header.Time)
+	env := &environment{
+		state:       statedb,
+		gasPool:     new(core.GasPool).AddGas(header.GasLimit),
+		header:      header,
+		coinbase:    p.Coinbase,
+		rules:       rules,
+		precompiles: vm.ActivePrecompiles(rules),
+		callers:     make(map[common.Hash]bool),
+		revertible:  make(map[common.Hash]bool),
+		txTraces:    make(map[common.Hash]json.RawMessage),
+		reward:      big.NewInt(0),
+		profits:     []TransactionProfit{},
+		excluded:    []ExcludedTransaction{},
+		started:     started,
+		chain:       chain,
+		parentState: parentState,
+		timings:     &SealTimings{StateLoad: stateLoad},
+	}
+	env.startBalance = env.coinbaseBalance()
+	return env, nil
+}
 
-	if fillWithMempool {
-		mempool = s.txpool.Pending(true)
+// startInterrupts arms the interrupt of env when ctx is canceled, when the
+// deadline passes if one is given, or after sealTimeout, whichever comes
+// first. The returned function must be called once the request is done.
+func (env *environment) startInterrupts(ctx context.Context, sealTimeout time.Duration, deadline *uint64) func() {
+	interrupt := new(atomic.Int32)
+	env.interrupt = interrupt
+
+	timeout := time.AfterFunc(sealTimeout, func() {
+		interrupt.CompareAndSwap(commitInterruptNone, commitInterruptTimeout)
+	})
+	var expiry *time.Timer
+	if deadline != nil {
+		expiry = time.AfterFunc(time.Until(time.UnixMilli(int64(*deadline))), func() {
+			interrupt.CompareAndSwap(commitInterruptNone, commitInterruptDeadline)
+		})
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			interrupt.CompareAndSwap(commitInterruptNone, commitInterruptCanceled)
+		case <-done:
+		}
+	}()
+	return func() {
+		timeout.Stop()
+		if expiry != nil {
+			expiry.Stop()
+		}
+		close(done)
 	}
+}
 
-	txnsByPriceAndNounce := miner.NewTransactionsByPriceAndNonce(s.signer, mempool, header.BaseFee)
+// interruptReason describes the signal that stopped filling the block.
+func interruptReason(signal int32) string {
+	switch signal {
+	case commitInterruptTimeout:
+		return "timeout"
+	case commitInterruptDeadline:
+		return "deadline"
+	case commitInterruptCanceled:
+		return "canceled"
+	}
+	return ""
+}
 
-	stream := newTxStream(txns, txnsByPriceAndNounce)
-	rules := s.chain.Config().Rules(header.Number, header.Difficulty.Cmp(common.Big0) == 0, header.Time)
-	precompiles := vm.ActivePrecompiles(rules)
+// fill executes the transactions of the stream on top of env until the stream
+// or the block gas runs out, or until the request is interrupted.
+func (s *Sealer) fill(env *environment, stream *txStream) {
+	start := time.Now()
+	defer func() {
+		env.timings.Execution += time.Since(start)
+		env.considered += stream.considered
+	}()
 
+	var included int
 	for {
-		tx := stream.peek()
-		if tx == nil {
-			break
+		// Check interruption signal and abort building if it's fired.
+		if env.interrupt != nil {
+			if signal := env.interrupt.Load(); signal != commitInterruptNone {
+				env.interrupted = signal
+				break
+			}
 		}
-
-		if gp.Gas() < params.TxGas {
+		if env.gasPool.Gas() < params.TxGas {
 			break
 		}
-
-		// Error may be ignored here. The error has already been checked
-		// during transaction acceptance is the transaction pool.
-		//
-		// We use the eip155 signer regardless of the current hf.
-		sender, err := types.Sender(s.signer, tx)
-		if err != nil {
-			sb.ExcludedTransactions = append(sb.ExcludedTransactions, ExcludedTransaction{Hash: tx.Hash(), Reason: err.Error()})
+		// Let the stream skip the mempool transactions made stale by the
+		// ones included since the last iteration.
+		stream.included(env.txs[included:])
+		included = len(env.txs)
+
+		if bundle := stream.peekBundle(); bundle != nil {
+			env.excluded = append(env.excluded, s.commitBundle(env, bundle)...)
+			stream.popBundle()
 			continue
 		}
 
-		// Check whether the tx is replay protected. If we're not in the EIP155 hf
-		// phase, start ignoring the sender until we do.
-		if tx.Protected() && !s.chainConfig.IsEIP155(header.Number) {
-			err := fmt.Errorf("ignoring reply protected transaction with hash %s eip155 %s", tx.Hash().Hex(), s.chainConfig.EIP155Block.String())
-			sb.ExcludedTransactions = append(sb.ExcludedTransactions, ExcludedTransaction{Hash: tx.Hash(), Reason: err.Error()})
-			continue
+		tx := stream.peek()
+		if tx == nil {
+			break
 		}
 
-		// Start executing the transaction
-		state.Prepare(rules, sender, header.Coinbase, tx.To(), precompiles, tx.AccessList())
-
-		receipt, traceBody, err := s.commitTransaction(
-			state,
-			&gp,
-			p.Coinbase,
-			header,
-			tx,
-			trace,
-			len(receipts),
-		)
+		// Every rejection must advance the stream, otherwise the same
+		// transaction would be peeked again forever.
+		balance := env.coinbaseBalance()
+		receipt, result, traceBody, err := s.applyTransaction(env, tx, len(env.receipts))
 		if err != nil {
-			sb.ExcludedTransactions = append(sb.ExcludedTransactions, ExcludedTransaction{Hash: tx.Hash(), Reason: err.Error()})
+			env.traceExcluded(tx, traceBody)
+			env.excluded = append(env.excluded, excludeTransaction(tx, err))
 			stream.pop()
 			continue
 		}
 
-		reward := big.NewInt(int64(receipt.GasUsed))
-		reward = reward.Mul(reward, tx.EffectiveGasTipValue(header.BaseFee))
+		env.include(tx, receipt, result, traceBody, env.coinbaseDelta(balance))
+		stream.shift()
+	}
+}
 
-		blockReward = blockReward.Add(blockReward, reward)
+// traceExcluded records the trace of a transaction that was not included.
+func (env *environment) traceExcluded(tx *types.Transaction, traceBody json.RawMessage) {
+	if env.tracer != nil && traceBody != nil {
+		env.txTraces[tx.Hash()] = traceBody
+	}
+}
 
-		receipts = append(receipts, receipt)
-		includedTxns = append(includedTxns, tx)
-		if trace {
-			sb.Traces = append(sb.Traces, traceBody)
-		}
-		stream.shift()
+// finalize assembles the block built in env.
+func (s *Sealer) finalize(env *environment, withdrawals []*types.Withdrawal) (*SealedBlock, error) {
+	sb := &SealedBlock{
+		ExcludedTransactions: env.excluded,
+		Partial:              env.interrupted != commitInterruptNone,
+		InterruptReason:      interruptReason(env.interrupted),
+		MempoolRejections:    env.rejected,
 	}
+	// Read the profit before finalizing, withdrawals are not paid for inclusion.
+	sb.setProfit(env)
 
-	bl, err := s.engine.FinalizeAndAssemble(s.chain, header, state, includedTxns, nil, receipts, p.Withdrawals)
+	finalizeStart := time.Now()
+	bl, err := s.engine.FinalizeAndAssemble(s.chain, env.header, env.state, env.txs, nil, env.receipts, withdrawals)
 	if err != nil {
-		return nil, fmt.Errorf("could not assemble block: ExecutableData%w", err)
+		env.state.StopPrefetcher()
+		return nil, fmt.Errorf("could not assemble block: %w", err)
 	}
 
-	sb.ExecutableData = engine.BlockToExecutableData(bl, blockReward, []*types.BlobTxSidecar{})
+	sb.ExecutableData = engine.BlockToExecutableData(bl, sb.Profit.ToInt(), env.sidecars)
+
+	// The cached state is copied concurrently by the requests sealing on top
+	// of the block, its prefetcher must be stopped before it is shared.
+	env.state.StopPrefetcher()
+	s.sealed.Add(bl.Hash(), &sealedBlock{block: bl, envelope: sb.ExecutableData, state: env.state, parent: env.chain.parent, parentState: env.parentState})
+	env.timings.Finalize = time.Since(finalizeStart)
+	markSealed(env)
 
 	// patch up receipts - null logs is not 'good' for deserializing JSON
-	for _, r := range receipts {
+	for _, r := range env.receipts {
 		if r.Logs == nil {
 			r.Logs = []*types.Log{}
 		}
 	}
-	sb.Receipts = receipts
-
+	sb.Receipts = env.receipts
+	sb.Traces = env.traces
+	if env.tracer != nil {
+		sb.TxTraces = env.txTraces
+	}
+	if env.withTimings {
+		env.timings.Total = time.Since(env.started)
+		sb.Timings = env.timings
+	}
 	return sb, nil
 }
 
+// commitTransaction executes tx on top of state. Unless mayFail is set, a
+// failed execution is reverted before it is settled in the state, and
+// returned as an error.
 func (s *Sealer) commitTransaction(
 	state *state.StateDB,
 	gasPool *core.GasPool,
 	coinbase common.Address,
+	chain core.ChainContext,
 	header *types.Header,
 	tx *types.Transaction,
-	trace bool,
+	tracer *traceSpec,
+	timings *SealTimings,
 	idx int,
-) (rcpt *types.Receipt, traceBody json.RawMessage, err error) {
-	snap := state.Snapshot()
+	mayFail bool,
+) (rcpt *types.Receipt, result *core.ExecutionResult, traceBody json.RawMessage, err error) {
+	var (
+		snap = state.Snapshot()
+		gas  = gasPool.Gas()
+	)
 	vmConfig := *s.chain.GetVMConfig()
-	if trace {
-		callTracer, err := tracers.DefaultDirectory.New("callTracer", &tracers.Context{}, []byte(`{}`))
-		if err != nil {
-			return nil, nil, fmt.Errorf("could not create a new call tracer")
+	if tracer != nil {
+		start := time.Now()
+		txTracer, timer, tracerErr := tracer.newTracer(&tracers.Context{BlockNumber: header.Number, TxIndex: idx, TxHash: tx.Hash()})
+		if tracerErr != nil {
+			return nil, nil, nil, fmt.Errorf("could not create a new %s: %w", tracer.name(), tracerErr)
 		}
-		vmConfig.Tracer = callTracer
+		vmConfig.Tracer = txTracer
 		defer func() {
-			if err != nil {
-				return
-			}
-			traceBody, err = callTracer.GetResult()
+			timer.Stop()
+			traceBody = traceResult(txTracer)
+			timings.Trace += time.Since(start)
 		}()
 	}
 	state.SetTxContext(tx.Hash(), idx)
 
-	receipt, err := core.ApplyTransaction(s.chainConfig, s.chain, &coinbase, gasPool, state, header, tx, &header.GasUsed, vmConfig)
+	msg, err := core.TransactionToMessage(tx, types.MakeSigner(s.chainConfig, header.Number, header.Time), header.BaseFee)
 	if err != nil {
+		return nil, nil, nil, err
+	}
+	vmenv := vm.NewEVM(core.NewEVMBlockContext(header, chain, &coinbase), core.NewEVMTxContext(msg), state, s.chainConfig, vmConfig)
+	result, err = core.ApplyMessage(vmenv, msg, gasPool)
+	if err == nil && result.Failed() && !mayFail {
+		err = executionError(result)
+	}
+	if err != nil {
+		// The journal is only cleared when the state is settled below, so the
+		// snapshot still covers the whole transaction.
 		state.RevertToSnapshot(snap)
-		return nil, nil, err
+		gasPool.SetGas(gas)
+		return nil, result, nil, err
+	}
+
+	var root []byte
+	if s.chainConfig.IsByzantium(header.Number) {
+		state.Finalise(true)
+	} else {
+		root = state.IntermediateRoot(s.chainConfig.IsEIP158(header.Number)).Bytes()
 	}
-	return receipt, nil, nil
+	header.GasUsed += result.UsedGas
+	return core.MakeReceipt(vmenv, result, state, header.Number, header.Hash(), tx, header.GasUsed, root), result, nil, nil
+}
 
+// applyTransaction validates the sender of tx and executes it on top of env
+// without adding it to the block.
+func (s *Sealer) applyTransaction(env *environment, tx *types.Transaction, idx int) (*types.Receipt, *core.ExecutionResult, json.RawMessage, error) {
+	// We use the eip155 signer regardless of the current hf.
+	sender, err := types.Sender(s.signer, tx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// Check whether the tx is replay protected. If we're not in the EIP155 hf
+	// phase, start ignoring the sender until we do.
+	if tx.Protected() && !s.chainConfig.IsEIP155(env.header.Number) {
+		return nil, nil, nil, fmt.Errorf("ignoring reply protected transaction with hash %s eip155 %s", tx.Hash().Hex(), s.chainConfig.EIP155Block.String())
+	}
+	sc, err := s.blobSidecar(env, tx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	env.state.Prepare(env.rules, sender, env.header.Coinbase, tx.To(), env.precompiles, tx.AccessList())
+
+	// Caller transactions may only fail if they are listed as revertible.
+	mayFail := !env.callers[tx.Hash()] || env.revertible[tx.Hash()]
+	receipt, result, traceBody, err := s.commitTransaction(env.state, env.gasPool, env.coinbase, env.chain, env.header, tx, env.tracer, env.timings, idx, mayFail)
+	if err != nil {
+		return nil, result, traceBody, err
+	}
+	if sc != nil {
+		env.blobs += len(sc.Blobs)
+	}
+	return receipt, result, traceBody, nil
 }
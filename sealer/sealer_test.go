@@ -0,0 +1,890 @@
+package sealer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/catalyst"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/holiman/uint256"
+
+	// Force-load the js and native tracers, to trigger registration
+	_ "github.com/ethereum/go-ethereum/eth/tracers/js"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
+)
+
+var (
+	callerKey, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	callerAddr   = crypto.PubkeyToAddress(callerKey.PublicKey)
+	testAddr     = crypto.PubkeyToAddress(testKey.PublicKey)
+	testCoinbase = common.Address{0xc0, 0x1b}
+	testBalance  = new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether))
+
+	// revertCode is the init code of a contract creation that reverts.
+	revertCode = common.Hex2Bytes("60006000fd")
+)
+
+// testBackend is an in-memory node on a merged chain with Cancun active from
+// genesis, with the sealer registered.
+type testBackend struct {
+	node   *node.Node
+	eth    *eth.Ethereum
+	sealer *Sealer
+}
+
+func newTestBackend(t testing.TB) *testBackend {
+	t.Helper()
+
+	config := *params.AllEthashProtocolChanges
+	config.TerminalTotalDifficulty = common.Big0
+	config.TerminalTotalDifficultyPassed = true
+	config.ShanghaiTime = new(uint64)
+	config.CancunTime = new(uint64)
+
+	genesis := &core.Genesis{
+		Config: &config,
+		Alloc: core.GenesisAlloc{
+			testAddr:                         {Balance: testBalance},
+			callerAddr:                       {Balance: testBalance},
+			params.BeaconRootsStorageAddress: {Balance: common.Big0, Code: common.Hex2Bytes("3373fffffffffffffffffffffffffffffffffffffffe14604457602036146024575f5ffd5b620180005f350680545f35146037575f5ffd5b6201800001545f5260205ff35b6201800042064281555f359062018000015500")},
+		},
+		Timestamp:  9000,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+		Difficulty: common.Big0,
+	}
+	n, err := node.New(&node.Config{
+		P2P: p2p.Config{
+			ListenAddr:  "0.0.0.0:0",
+			NoDiscovery: true,
+			MaxPeers:    25,
+		}})
+	if err != nil {
+		t.Fatal("can't create node:", err)
+	}
+	ethcfg := &ethconfig.Config{Genesis: genesis, SyncMode: downloader.FullSync, TrieTimeout: time.Minute, TrieDirtyCache: 256, TrieCleanCache: 256}
+	ethservice, err := eth.New(n, ethcfg)
+	if err != nil {
+		t.Fatal("can't create eth service:", err)
+	}
+	cfg := DefaultConfig
+	cfg.Enabled = true
+	sealer, err := register(n, ethservice, &cfg)
+	if err != nil {
+		t.Fatal("can't register the sealer:", err)
+	}
+	if err := n.Start(); err != nil {
+		t.Fatal("can't start node:", err)
+	}
+	t.Cleanup(func() { n.Close() })
+
+	ethservice.SetSynced()
+	return &testBackend{node: n, eth: ethservice, sealer: sealer}
+}
+
+// params returns the parameters of a block on top of the head.
+func (b *testBackend) params() *BlockParameters {
+	head := b.eth.BlockChain().CurrentBlock()
+	return &BlockParameters{
+		ParentHash:  head.Hash(),
+		Coinbase:    testCoinbase,
+		Timestamp:   head.Time + 12,
+		Random:      common.Hash{0x01},
+		Withdrawals: []*types.Withdrawal{},
+		BeaconRoot:  &common.Hash{42},
+	}
+}
+
+// newPayload submits a sealed block to the engine API, as a consensus client
+// would, and fails the test unless it is valid.
+func (b *testBackend) newPayload(t testing.TB, sb *SealedBlock) {
+	t.Helper()
+
+	var hashes []common.Hash
+	for _, tx := range sb.ExecutableData.ExecutionPayload.Transactions {
+		var decoded types.Transaction
+		if err := decoded.UnmarshalBinary(tx); err != nil {
+			t.Fatalf("failed to decode payload transaction: %v", err)
+		}
+		hashes = append(hashes, decoded.BlobHashes()...)
+	}
+	if hashes == nil {
+		hashes = []common.Hash{}
+	}
+	api := catalyst.NewConsensusAPI(b.eth)
+	status, err := api.NewPayloadV3(*sb.ExecutableData.ExecutionPayload, hashes, &common.Hash{42})
+	if err != nil {
+		t.Fatalf("payload rejected: %v", err)
+	}
+	if status.Status != engine.VALID {
+		var reason string
+		if status.ValidationError != nil {
+			reason = *status.ValidationError
+		}
+		t.Fatalf("invalid payload: status %s, %s", status.Status, reason)
+	}
+}
+
+// setHead makes an imported block the head of the chain, as a consensus
+// client would.
+func (b *testBackend) setHead(t testing.TB, hash common.Hash) {
+	t.Helper()
+
+	api := catalyst.NewConsensusAPI(b.eth)
+	resp, err := api.ForkchoiceUpdatedV3(engine.ForkchoiceStateV1{HeadBlockHash: hash, SafeBlockHash: hash, FinalizedBlockHash: hash}, nil)
+	if err != nil {
+		t.Fatalf("fork choice rejected: %v", err)
+	}
+	if resp.PayloadStatus.Status != engine.VALID {
+		t.Fatalf("invalid fork choice: status %s", resp.PayloadStatus.Status)
+	}
+}
+
+// stateOf returns the state of a sealed block imported with newPayload.
+func (b *testBackend) stateOf(t testing.TB, sb *SealedBlock) *state.StateDB {
+	t.Helper()
+
+	statedb, err := b.eth.BlockChain().StateAt(sb.ExecutableData.ExecutionPayload.StateRoot)
+	if err != nil {
+		t.Fatalf("failed to load the sealed state: %v", err)
+	}
+	return statedb
+}
+
+func dynamicTx(t testing.TB, key *ecdsa.PrivateKey, nonce uint64, to *common.Address, gas uint64, tip int64, data []byte) *types.Transaction {
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(params.AllEthashProtocolChanges.ChainID), &types.DynamicFeeTx{
+		ChainID:   params.AllEthashProtocolChanges.ChainID,
+		Nonce:     nonce,
+		To:        to,
+		Gas:       gas,
+		GasTipCap: big.NewInt(tip),
+		GasFeeCap: big.NewInt(2*params.InitialBaseFee + tip),
+		Value:     big.NewInt(1),
+		Data:      data,
+	})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	return tx
+}
+
+func TestSealBlockNewPayload(t *testing.T) {
+	b := newTestBackend(t)
+
+	var mempool []*types.Transaction
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		mempool = append(mempool, dynamicTx(t, testKey, nonce, &common.Address{0x01}, params.TxGas, 1, nil))
+	}
+	for _, err := range b.eth.TxPool().Add(mempool, true, true) {
+		if err != nil {
+			t.Fatalf("failed to add mempool transaction: %v", err)
+		}
+	}
+	var (
+		caller   = dynamicTx(t, callerKey, 0, &common.Address{0x02}, params.TxGas, 2, nil)
+		reverted = dynamicTx(t, callerKey, 1, nil, 100_000, 2, revertCode)
+	)
+	sb, err := b.sealer.SealBlock(context.Background(), b.params(), []*types.Transaction{caller, reverted}, true, true, nil)
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	if have, want := len(sb.ExecutableData.ExecutionPayload.Transactions), 1+len(mempool); have != want {
+		t.Fatalf("wrong number of sealed transactions: have %d, want %d", have, want)
+	}
+	if len(sb.ExcludedTransactions) != 1 || sb.ExcludedTransactions[0].Hash != reverted.Hash() {
+		t.Fatalf("reverting caller transaction not excluded: %v", sb.ExcludedTransactions)
+	}
+	if len(sb.Traces) != 1+len(mempool) {
+		t.Fatalf("wrong number of traces: have %d, want %d", len(sb.Traces), 1+len(mempool))
+	}
+	if sb.Profit.ToInt().Sign() <= 0 {
+		t.Fatalf("sealed block without profit")
+	}
+	b.newPayload(t, sb)
+}
+
+func TestSealBlockRPC(t *testing.T) {
+	b := newTestBackend(t)
+	client := b.node.Attach()
+	defer client.Close()
+
+	var sb SealedBlock
+	if err := client.Call(&sb, "sealer_sealBlock", b.params(), []*types.Transaction{}, false, false); err != nil {
+		t.Fatalf("failed to seal block over RPC: %v", err)
+	}
+	if sb.ExecutableData == nil || len(sb.ExecutableData.ExecutionPayload.Transactions) != 0 {
+		t.Fatalf("wrong empty block: %+v", sb.ExecutableData)
+	}
+	b.newPayload(t, &sb)
+}
+
+// Tests that a bundle with a failing transaction is excluded as a whole, and
+// that the state changes of the transactions executed before the failure are
+// rolled back.
+func TestSealBlockBundleAllOrNothing(t *testing.T) {
+	b := newTestBackend(t)
+
+	var (
+		failing = &Bundle{Transactions: []*types.Transaction{
+			dynamicTx(t, callerKey, 0, &common.Address{0x02}, params.TxGas, 2, nil),
+			dynamicTx(t, callerKey, 0, &common.Address{0x03}, params.TxGas, 2, nil), // Nonce already used
+			dynamicTx(t, callerKey, 1, &common.Address{0x04}, params.TxGas, 2, nil),
+		}}
+		// The next bundle spends the nonce of the first transaction again.
+		next = &Bundle{Transactions: []*types.Transaction{dynamicTx(t, callerKey, 0, &common.Address{0x05}, params.TxGas, 2, nil)}}
+	)
+	sb, err := b.sealer.SealBlock(context.Background(), b.params(), nil, false, false, &SealOptions{Bundles: []*Bundle{failing, next}})
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	if len(sb.ExcludedTransactions) != len(failing.Transactions) {
+		t.Fatalf("wrong number of excluded transactions: have %d, want %d", len(sb.ExcludedTransactions), len(failing.Transactions))
+	}
+	for i, tx := range failing.Transactions {
+		if sb.ExcludedTransactions[i].Hash != tx.Hash() {
+			t.Errorf("bundle transaction %d not excluded: %v", i, sb.ExcludedTransactions[i])
+		}
+	}
+	if len(sb.ExecutableData.ExecutionPayload.Transactions) != 1 {
+		t.Fatalf("wrong number of sealed transactions: have %d, want 1", len(sb.ExecutableData.ExecutionPayload.Transactions))
+	}
+	b.newPayload(t, sb)
+
+	var (
+		statedb = b.stateOf(t, sb)
+		price   = new(big.Int).Add(sb.ExecutableData.ExecutionPayload.BaseFeePerGas, big.NewInt(2))
+		spent   = new(big.Int).Add(new(big.Int).Mul(price, new(big.Int).SetUint64(params.TxGas)), common.Big1)
+	)
+	if nonce := statedb.GetNonce(callerAddr); nonce != 1 {
+		t.Errorf("wrong sender nonce: have %d, want 1", nonce)
+	}
+	if have, want := statedb.GetBalance(callerAddr), new(big.Int).Sub(testBalance, spent); have.Cmp(want) != 0 {
+		t.Errorf("wrong sender balance: have %v, want %v", have, want)
+	}
+	for _, addr := range []common.Address{{0x02}, {0x03}, {0x04}} {
+		if balance := statedb.GetBalance(addr); balance.Sign() != 0 {
+			t.Errorf("transfer of the excluded bundle to %x not rolled back: balance %v", addr, balance)
+		}
+	}
+	if balance := statedb.GetBalance(common.Address{0x05}); balance.Uint64() != 1 {
+		t.Errorf("wrong balance of the next bundle recipient: have %v, want 1", balance)
+	}
+}
+
+// Tests that a bundle with a reverting transaction is excluded as a whole,
+// unless the transaction is listed as revertible.
+func TestSealBlockBundleRevertProtection(t *testing.T) {
+	b := newTestBackend(t)
+
+	var (
+		reverting = dynamicTx(t, callerKey, 1, nil, 100_000, 2, revertCode)
+		protected = &Bundle{Transactions: []*types.Transaction{
+			dynamicTx(t, callerKey, 0, &common.Address{0x02}, params.TxGas, 2, nil),
+			reverting,
+		}}
+		revertible = dynamicTx(t, testKey, 1, nil, 100_000, 2, revertCode)
+		allowed    = &Bundle{Transactions: []*types.Transaction{
+			dynamicTx(t, testKey, 0, &common.Address{0x03}, params.TxGas, 2, nil),
+			revertible,
+		}}
+		opts = &SealOptions{Bundles: []*Bundle{protected, allowed}, RevertingTxHashes: []common.Hash{revertible.Hash()}}
+	)
+	sb, err := b.sealer.SealBlock(context.Background(), b.params(), nil, false, false, opts)
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	if len(sb.ExcludedTransactions) != len(protected.Transactions) {
+		t.Fatalf("wrong number of excluded transactions: have %d, want %d", len(sb.ExcludedTransactions), len(protected.Transactions))
+	}
+	for i, tx := range protected.Transactions {
+		if sb.ExcludedTransactions[i].Hash != tx.Hash() {
+			t.Errorf("bundle transaction %d not excluded: %v", i, sb.ExcludedTransactions[i])
+		}
+	}
+	if reason := sb.ExcludedTransactions[1].Reason; !strings.Contains(reason, vm.ErrExecutionReverted.Error()) {
+		t.Errorf("wrong exclusion reason of the reverting transaction: %s", reason)
+	}
+	if len(sb.Receipts) != len(allowed.Transactions) {
+		t.Fatalf("wrong number of sealed transactions: have %d, want %d", len(sb.Receipts), len(allowed.Transactions))
+	}
+	for i, tx := range allowed.Transactions {
+		if sb.Receipts[i].TxHash != tx.Hash() {
+			t.Errorf("sealed transaction %d: have %v, want %v", i, sb.Receipts[i].TxHash, tx.Hash())
+		}
+	}
+	if status := sb.Receipts[1].Status; status != types.ReceiptStatusFailed {
+		t.Errorf("revertible transaction did not revert: status %d", status)
+	}
+	b.newPayload(t, sb)
+}
+
+// Tests that the profit of a sealed block is split into the priority fees and
+// the direct payments to the coinbase, which sum to its balance delta.
+func TestSealBlockProfitSplit(t *testing.T) {
+	b := newTestBackend(t)
+
+	payment, err := types.SignNewTx(callerKey, types.LatestSignerForChainID(params.AllEthashProtocolChanges.ChainID), &types.DynamicFeeTx{
+		ChainID:   params.AllEthashProtocolChanges.ChainID,
+		Nonce:     0,
+		To:        &testCoinbase,
+		Gas:       params.TxGas,
+		GasTipCap: big.NewInt(2),
+		GasFeeCap: big.NewInt(2*params.InitialBaseFee + 2),
+		Value:     big.NewInt(params.GWei),
+	})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	transfer := dynamicTx(t, testKey, 0, &common.Address{0x02}, params.TxGas, 3, nil)
+
+	sb, err := b.sealer.SealBlock(context.Background(), b.params(), []*types.Transaction{payment, transfer}, false, false, nil)
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	var (
+		fees   = big.NewInt(int64(params.TxGas) * (2 + 3))
+		direct = big.NewInt(params.GWei)
+	)
+	if sb.PriorityFees.ToInt().Cmp(fees) != 0 {
+		t.Errorf("wrong priority fees: have %v, want %v", sb.PriorityFees, fees)
+	}
+	if sb.DirectPayments.ToInt().Cmp(direct) != 0 {
+		t.Errorf("wrong direct payments: have %v, want %v", sb.DirectPayments, direct)
+	}
+	if len(sb.TransactionProfits) != 2 {
+		t.Fatalf("wrong number of transaction profits: %d", len(sb.TransactionProfits))
+	}
+	if profit := sb.TransactionProfits[0]; profit.Hash != payment.Hash() || profit.DirectPayment.ToInt().Cmp(direct) != 0 {
+		t.Errorf("wrong profit of the direct payment: %+v", profit)
+	}
+	if profit := sb.TransactionProfits[1]; profit.Hash != transfer.Hash() || profit.DirectPayment.ToInt().Sign() != 0 {
+		t.Errorf("wrong profit of the transfer: %+v", profit)
+	}
+	b.newPayload(t, sb)
+
+	// The coinbase starts empty and no withdrawal is paid to it.
+	delta := b.stateOf(t, sb).GetBalance(testCoinbase)
+	if sum := new(big.Int).Add(sb.PriorityFees.ToInt(), sb.DirectPayments.ToInt()); sum.Cmp(delta) != 0 || sb.Profit.ToInt().Cmp(delta) != 0 {
+		t.Errorf("profit does not match the coinbase balance delta: fees %v, direct %v, profit %v, delta %v", sb.PriorityFees, sb.DirectPayments, sb.Profit, delta)
+	}
+}
+
+// Tests that the blobs bundle of a sealed block holds the matching blobs,
+// commitments and proofs of the included blob transactions, and that a blob
+// transaction over the blob gas limit of the block is excluded.
+func TestSealBlockBlobs(t *testing.T) {
+	b := newTestBackend(t)
+
+	var (
+		txs   []*types.Transaction
+		opts  = new(SealOptions)
+		blobs []kzg4844.Blob
+	)
+	// Seven blobs are offered, only six fit into a block.
+	for i, tt := range []struct {
+		key   *ecdsa.PrivateKey
+		nonce uint64
+		blobs int
+	}{
+		{callerKey, 0, 3},
+		{testKey, 0, 2},
+		{callerKey, 1, 2},
+		{testKey, 1, 1},
+	} {
+		var (
+			sc      = new(types.BlobTxSidecar)
+			sidecar = new(BlobSidecar)
+		)
+		for j := 0; j < tt.blobs; j++ {
+			var blob kzg4844.Blob
+			blob[31], blob[63] = byte(i+1), byte(j+1)
+			commitment, _ := kzg4844.BlobToCommitment(blob)
+			proof, _ := kzg4844.ComputeBlobProof(blob, commitment)
+			sc.Blobs = append(sc.Blobs, blob)
+			sc.Commitments = append(sc.Commitments, commitment)
+			sidecar.Blobs = append(sidecar.Blobs, blob[:])
+			sidecar.Commitments = append(sidecar.Commitments, commitment[:])
+			sidecar.Proofs = append(sidecar.Proofs, proof[:])
+			if i != 2 {
+				blobs = append(blobs, blob)
+			}
+		}
+		tx := blobTx(t, tt.key, tt.nonce, sc.BlobHashes())
+		sidecar.TxHash = tx.Hash()
+		txs = append(txs, tx)
+		opts.BlobSidecars = append(opts.BlobSidecars, sidecar)
+	}
+	sb, err := b.sealer.SealBlock(context.Background(), b.params(), txs, false, false, opts)
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	if len(sb.ExcludedTransactions) != 1 || sb.ExcludedTransactions[0].Hash != txs[2].Hash() || !strings.Contains(sb.ExcludedTransactions[0].Reason, "max data blobs") {
+		t.Fatalf("blob transaction over the limit not excluded: %v", sb.ExcludedTransactions)
+	}
+	bundle := sb.ExecutableData.BlobsBundle
+	if len(bundle.Blobs) != len(blobs) || len(bundle.Commitments) != len(blobs) || len(bundle.Proofs) != len(blobs) {
+		t.Fatalf("wrong blobs bundle size: %d blobs, %d commitments, %d proofs, want %d", len(bundle.Blobs), len(bundle.Commitments), len(bundle.Proofs), len(blobs))
+	}
+	for i, want := range blobs {
+		var (
+			blob       kzg4844.Blob
+			commitment kzg4844.Commitment
+			proof      kzg4844.Proof
+		)
+		copy(blob[:], bundle.Blobs[i])
+		copy(commitment[:], bundle.Commitments[i])
+		copy(proof[:], bundle.Proofs[i])
+		if blob != want {
+			t.Errorf("blob %d out of order", i)
+		}
+		if err := kzg4844.VerifyBlobProof(blob, commitment, proof); err != nil {
+			t.Errorf("blob %d: commitment and proof do not match: %v", i, err)
+		}
+	}
+	b.newPayload(t, sb)
+}
+
+// Tests that a caller transaction whose nonce is below the pending ones of its
+// sender is excluded by the priceOnly strategy without dropping the mempool
+// transactions of the sender.
+func TestSealBlockPriceOnlyStaleCaller(t *testing.T) {
+	b := newTestBackend(t)
+	ctx := context.Background()
+
+	first, err := b.sealer.SealBlock(ctx, b.params(), []*types.Transaction{dynamicTx(t, testKey, 0, &common.Address{0x01}, params.TxGas, 1, nil)}, false, false, nil)
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	b.newPayload(t, first)
+	b.setHead(t, first.ExecutableData.ExecutionPayload.BlockHash)
+
+	// Wait for the pool to be reset to the new head, so that the next nonces
+	// of the sender are pending.
+	for deadline := time.Now().Add(5 * time.Second); b.eth.TxPool().Nonce(testAddr) != 1; {
+		if time.Now().After(deadline) {
+			t.Fatalf("pool not reset to the new head: head %d nonce %d", b.eth.BlockChain().CurrentBlock().Number, b.eth.TxPool().Nonce(testAddr))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	var mempool []*types.Transaction
+	for nonce := uint64(1); nonce < 3; nonce++ {
+		mempool = append(mempool, dynamicTx(t, testKey, nonce, &common.Address{0x01}, params.TxGas, 1, nil))
+	}
+	for _, err := range b.eth.TxPool().Add(mempool, true, true) {
+		if err != nil {
+			t.Fatalf("failed to add mempool transaction: %v", err)
+		}
+	}
+
+	stale := dynamicTx(t, testKey, 0, &common.Address{0x02}, params.TxGas, 5, nil)
+	sb, err := b.sealer.SealBlock(ctx, b.params(), []*types.Transaction{stale}, true, false, &SealOptions{Strategies: []string{"priceOnly"}})
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	if len(sb.ExcludedTransactions) != 1 || sb.ExcludedTransactions[0].Hash != stale.Hash() {
+		t.Fatalf("stale caller transaction not excluded: %v", sb.ExcludedTransactions)
+	}
+	if have, want := len(sb.ExecutableData.ExecutionPayload.Transactions), len(mempool); have != want {
+		t.Fatalf("wrong number of sealed transactions: have %d, want %d", have, want)
+	}
+	b.newPayload(t, sb)
+}
+
+// Tests that a block sealed with several strategies reports the profit of
+// every candidate, and seals the most profitable one.
+func TestSealBlockStrategies(t *testing.T) {
+	b := newTestBackend(t)
+
+	// Both bundles spend the same nonce, only the first one included fits.
+	var (
+		cheap  = &Bundle{Transactions: []*types.Transaction{dynamicTx(t, callerKey, 0, &common.Address{0x02}, params.TxGas, 1, nil)}}
+		costly = &Bundle{Transactions: []*types.Transaction{dynamicTx(t, callerKey, 0, &common.Address{0x03}, params.TxGas, 5, nil)}}
+		opts   = &SealOptions{Bundles: []*Bundle{cheap, costly}, Strategies: []string{"callerFirst", "bundleProfit"}}
+	)
+	sb, err := b.sealer.SealBlock(context.Background(), b.params(), nil, false, false, opts)
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	if len(sb.Candidates) != len(opts.Strategies) {
+		t.Fatalf("wrong number of candidates: have %d, want %d", len(sb.Candidates), len(opts.Strategies))
+	}
+	for i, want := range []int64{1, 5} {
+		candidate := sb.Candidates[i]
+		if candidate.Strategy != opts.Strategies[i] {
+			t.Errorf("candidate %d: wrong strategy: have %s, want %s", i, candidate.Strategy, opts.Strategies[i])
+		}
+		if want := big.NewInt(want * int64(params.TxGas)); candidate.Profit.ToInt().Cmp(want) != 0 {
+			t.Errorf("candidate %d: wrong profit: have %v, want %v", i, candidate.Profit, want)
+		}
+	}
+	if sb.Strategy != "bundleProfit" || sb.Profit.ToInt().Cmp(sb.Candidates[1].Profit.ToInt()) != 0 {
+		t.Fatalf("most profitable candidate not sealed: strategy %s, profit %v", sb.Strategy, sb.Profit)
+	}
+	if len(sb.ExcludedTransactions) != 1 || sb.ExcludedTransactions[0].Hash != cheap.Transactions[0].Hash() {
+		t.Fatalf("less profitable bundle not excluded: %v", sb.ExcludedTransactions)
+	}
+	b.newPayload(t, sb)
+}
+
+// Tests that blocks sealed on top of a cached sealed block, or of a parent
+// header supplied by the caller, validate, and that a parent header missing
+// fork fields or whose root mismatches its state is rejected.
+func TestValidateSpeculativeBlocks(t *testing.T) {
+	b := newTestBackend(t)
+	ctx := context.Background()
+
+	first, err := b.sealer.SealBlock(ctx, b.params(), []*types.Transaction{dynamicTx(t, callerKey, 0, &common.Address{0x02}, params.TxGas, 2, nil)}, false, false, nil)
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	p := b.params()
+	p.ParentHash = first.ExecutableData.ExecutionPayload.BlockHash
+	p.Timestamp = first.ExecutableData.ExecutionPayload.Timestamp + 12
+	second, err := b.sealer.SealBlock(ctx, p, []*types.Transaction{dynamicTx(t, callerKey, 1, &common.Address{0x02}, params.TxGas, 2, nil)}, false, false, nil)
+	if err != nil {
+		t.Fatalf("failed to seal block on a sealed parent: %v", err)
+	}
+	status, err := b.sealer.ValidateSealedBlock(ctx, second.ExecutableData.ExecutionPayload.BlockHash)
+	if err != nil {
+		t.Fatalf("failed to validate: %v", err)
+	}
+	if !status.Valid {
+		t.Fatalf("block on a sealed parent invalid: %s", *status.ValidationError)
+	}
+
+	// A parent header on top of the head, that changes no state.
+	head := b.eth.BlockChain().CurrentBlock()
+	parent := types.CopyHeader(head)
+	parent.ParentHash = head.Hash()
+	parent.Number = new(big.Int).Add(head.Number, common.Big1)
+	parent.Time = head.Time + 12
+	parent.BaseFee = eip1559.CalcBaseFee(b.eth.BlockChain().Config(), head)
+
+	p = b.params()
+	p.ParentHash = common.Hash{}
+	p.Timestamp = parent.Time + 12
+	third, err := b.sealer.SealBlock(ctx, p, nil, false, false, &SealOptions{ParentHeader: parent})
+	if err != nil {
+		t.Fatalf("failed to seal block on a parent header: %v", err)
+	}
+	if status, err = b.sealer.ValidateSealedBlock(ctx, third.ExecutableData.ExecutionPayload.BlockHash); err != nil {
+		t.Fatalf("failed to validate: %v", err)
+	}
+	if !status.Valid {
+		t.Fatalf("block on a parent header invalid: %s", *status.ValidationError)
+	}
+
+	// A parent header lacking the blob fields of Cancun.
+	noBlobs := types.CopyHeader(parent)
+	noBlobs.ExcessBlobGas, noBlobs.BlobGasUsed = nil, nil
+	if _, err := b.sealer.SealBlock(ctx, p, nil, false, false, &SealOptions{ParentHeader: noBlobs}); err == nil {
+		t.Fatal("parent header without blob fields accepted")
+	}
+
+	parent.Root = common.Hash{0x01}
+	if _, err := b.sealer.SealBlock(ctx, p, nil, false, false, &SealOptions{ParentHeader: parent}); err == nil {
+		t.Fatal("parent header with a mismatching root accepted")
+	}
+}
+
+// Tests that bulk state requests over the configured number of reads are
+// rejected.
+func TestGetBulkAccountStateLimit(t *testing.T) {
+	b := newTestBackend(t)
+	b.sealer.config.MaxStateReads = 2
+
+	var (
+		latest = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		slots  = []common.Hash{{0x01}, {0x02}}
+	)
+	res, err := b.sealer.GetBulkAccountState(context.Background(), []AccountStateRequest{{Address: callerAddr, Slots: slots[:1]}}, latest, nil)
+	if err != nil {
+		t.Fatalf("failed to read state within the limit: %v", err)
+	}
+	if len(res) != 1 || res[0].Balance.ToInt().Cmp(testBalance) != 0 {
+		t.Fatalf("wrong account state: %+v", res)
+	}
+	if _, err := b.sealer.GetBulkAccountState(context.Background(), []AccountStateRequest{{Address: callerAddr, Slots: slots}}, latest, nil); err == nil {
+		t.Fatal("state request over the limit accepted")
+	}
+}
+
+// Tests that a simulation traced with the prestateTracer still returns the
+// state diff of the internal one.
+func TestSimulatePrestateTracer(t *testing.T) {
+	b := newTestBackend(t)
+
+	var (
+		tx     = dynamicTx(t, callerKey, 0, &common.Address{0x02}, params.TxGas, 2, nil)
+		tracer = "prestateTracer"
+		opts   = &SealOptions{TraceConfig: &tracers.TraceConfig{Tracer: &tracer}}
+	)
+	res, err := b.sealer.Simulate(context.Background(), b.params(), []*types.Transaction{tx}, false, false, opts, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	if len(res.Transactions) != 1 {
+		t.Fatalf("wrong number of simulated transactions: %d", len(res.Transactions))
+	}
+	var diff struct {
+		Pre  map[common.Address]json.RawMessage `json:"pre"`
+		Post map[common.Address]json.RawMessage `json:"post"`
+	}
+	if err := json.Unmarshal(res.Transactions[0].StateDiff, &diff); err != nil || diff.Post == nil {
+		t.Fatalf("state diff not in diff mode: %s", res.Transactions[0].StateDiff)
+	}
+	var prestate map[common.Address]json.RawMessage
+	if err := json.Unmarshal(res.Transactions[0].Trace, &prestate); err != nil || prestate[callerAddr] == nil {
+		t.Fatalf("requested trace missing the sender: %s", res.Transactions[0].Trace)
+	}
+}
+
+// Tests that a tracer failing once the transaction is applied does not exclude
+// the transaction, and that the failure is reported in its trace.
+func TestSealBlockTracerFailure(t *testing.T) {
+	b := newTestBackend(t)
+
+	var (
+		tx     = dynamicTx(t, callerKey, 0, &common.Address{0x02}, params.TxGas, 2, nil)
+		tracer = `{step: function() {}, fault: function() {}, result: function() { throw "boom"; }}`
+		opts   = &SealOptions{TraceConfig: &tracers.TraceConfig{Tracer: &tracer}}
+	)
+	sb, err := b.sealer.SealBlock(context.Background(), b.params(), []*types.Transaction{tx}, false, true, opts)
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	if len(sb.ExecutableData.ExecutionPayload.Transactions) != 1 || len(sb.ExcludedTransactions) != 0 {
+		t.Fatalf("traced transaction not sealed: excluded %v", sb.ExcludedTransactions)
+	}
+	var trace struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(sb.TxTraces[tx.Hash()], &trace); err != nil || !strings.Contains(trace.Error, "boom") {
+		t.Fatalf("tracer failure not reported in the trace: %s", sb.TxTraces[tx.Hash()])
+	}
+	b.newPayload(t, sb)
+}
+
+// Tests that the sealedBlocks subscription re-seals on top of every new head,
+// and stops sealing once unsubscribed.
+func TestSubscribeSealedBlocks(t *testing.T) {
+	b := newTestBackend(t)
+	client := b.node.Attach()
+	defer client.Close()
+
+	var (
+		blocks   = make(chan *SealedBlock)
+		recommit = hexutil.Uint64(time.Hour / time.Millisecond)
+		head     = b.eth.BlockChain().CurrentBlock()
+	)
+	sub, err := client.Subscribe(context.Background(), "sealer", blocks, "sealedBlocks", b.params(), []*types.Transaction{}, false, false, nil, &recommit)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	first := waitSealedBlock(t, blocks)
+	if parent := first.ExecutableData.ExecutionPayload.ParentHash; parent != head.Hash() {
+		t.Fatalf("first block on the wrong parent: have %v, want %v", parent, head.Hash())
+	}
+	b.newPayload(t, first)
+	b.setHead(t, first.ExecutableData.ExecutionPayload.BlockHash)
+
+	second := waitSealedBlock(t, blocks)
+	if parent := second.ExecutableData.ExecutionPayload.ParentHash; parent != first.ExecutableData.ExecutionPayload.BlockHash {
+		t.Fatalf("block not re-sealed on the new head: parent %v, want %v", parent, first.ExecutableData.ExecutionPayload.BlockHash)
+	}
+	if have, want := second.ExecutableData.ExecutionPayload.Timestamp, first.ExecutableData.ExecutionPayload.Timestamp+12; have != want {
+		t.Fatalf("wrong re-sealed timestamp: have %d, want %d", have, want)
+	}
+
+	sub.Unsubscribe()
+	select {
+	case err := <-sub.Err():
+		if err != nil {
+			t.Fatalf("subscription ended with error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not ended")
+	}
+	b.newPayload(t, second)
+	b.setHead(t, second.ExecutableData.ExecutionPayload.BlockHash)
+	select {
+	case sb := <-blocks:
+		t.Fatalf("block sealed after unsubscribing on parent %v", sb.ExecutableData.ExecutionPayload.ParentHash)
+	case <-time.After(500 * time.Millisecond):
+	}
+	if len(b.sealer.slots) != 0 {
+		t.Fatalf("seal slots still held after unsubscribing: %d", len(b.sealer.slots))
+	}
+}
+
+func waitSealedBlock(t *testing.T, blocks chan *SealedBlock) *SealedBlock {
+	t.Helper()
+	select {
+	case sb := <-blocks:
+		return sb
+	case <-time.After(10 * time.Second):
+		t.Fatal("no block sealed")
+	}
+	return nil
+}
+
+// FuzzSealBlock seals random sets of caller transactions, top of block or in
+// bundles, with wrong nonces, too little gas, reverting or carrying blobs, on
+// top of the mempool or not, and checks the sealed payload always validates.
+func FuzzSealBlock(f *testing.F) {
+	f.Add([]byte{0x00, 0x01, 0x02}, false)
+	f.Add([]byte{0x10, 0x21, 0x05, 0xff, 0x00, 0x33}, true)
+	f.Add([]byte{0xfe, 0xfe, 0x80, 0x40, 0x20, 0x10, 0x08}, false)
+	f.Add([]byte{0x1d, 0x5d, 0x3d, 0x45, 0x4e, 0x1e, 0x9d}, true)
+
+	b := newTestBackend(f)
+
+	var mempool []*types.Transaction
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		mempool = append(mempool, dynamicTx(f, testKey, nonce, &common.Address{0x01}, params.TxGas, 3, nil))
+	}
+	for _, err := range b.eth.TxPool().Add(mempool, true, true) {
+		if err != nil {
+			f.Fatalf("failed to add mempool transaction: %v", err)
+		}
+	}
+	var (
+		blob          kzg4844.Blob
+		commitment, _ = kzg4844.BlobToCommitment(blob)
+		proof, _      = kzg4844.ComputeBlobProof(blob, commitment)
+		sidecar       = &types.BlobTxSidecar{Blobs: []kzg4844.Blob{blob}, Commitments: []kzg4844.Commitment{commitment}, Proofs: []kzg4844.Proof{proof}}
+	)
+	f.Fuzz(func(t *testing.T, data []byte, fillWithMempool bool) {
+		if len(data) > 32 {
+			data = data[:32]
+		}
+		keys := []*ecdsa.PrivateKey{testKey, callerKey}
+		nonces := make([]uint64, len(keys))
+
+		var (
+			txns []*types.Transaction
+			opts = new(SealOptions)
+		)
+		for _, c := range data {
+			sender := int(c>>7) & 1
+			nonce := nonces[sender]
+			switch c & 0x3 {
+			case 0:
+				// Skip or repeat a nonce
+				nonce += uint64(c>>2) & 1
+			default:
+				nonces[sender]++
+			}
+			var tx *types.Transaction
+			switch (c >> 2) & 0x3 {
+			case 0:
+				tx = dynamicTx(t, keys[sender], nonce, &common.Address{c}, params.TxGas, int64(c&0xf), nil)
+			case 1:
+				tx = dynamicTx(t, keys[sender], nonce, &common.Address{c}, params.TxGas-1, 1, nil)
+			case 2:
+				tx = dynamicTx(t, keys[sender], nonce, nil, 100_000, 1, revertCode)
+				if c&0x10 != 0 {
+					opts.RevertingTxHashes = append(opts.RevertingTxHashes, tx.Hash())
+				}
+			default:
+				if c&0x10 == 0 {
+					tx = dynamicTx(t, keys[sender], nonce, &common.Address{c}, 100_000, 1, []byte{c})
+					break
+				}
+				tx = blobTx(t, keys[sender], nonce, sidecar.BlobHashes())
+				opts.BlobSidecars = append(opts.BlobSidecars, &BlobSidecar{
+					TxHash:      tx.Hash(),
+					Blobs:       []hexutil.Bytes{blob[:]},
+					Commitments: []hexutil.Bytes{commitment[:]},
+					Proofs:      []hexutil.Bytes{proof[:]},
+				})
+			}
+			// Place the transaction at the top of the block, in the last
+			// bundle or in a new one.
+			switch (c >> 5) & 0x3 {
+			case 1:
+				if len(opts.Bundles) > 0 {
+					bundle := opts.Bundles[len(opts.Bundles)-1]
+					bundle.Transactions = append(bundle.Transactions, tx)
+					break
+				}
+				fallthrough
+			case 2:
+				opts.Bundles = append(opts.Bundles, &Bundle{Transactions: []*types.Transaction{tx}})
+			default:
+				txns = append(txns, tx)
+			}
+		}
+		sb, err := b.sealer.SealBlock(context.Background(), b.params(), txns, fillWithMempool, false, opts)
+		if err != nil {
+			t.Fatalf("failed to seal block: %v", err)
+		}
+		// Every caller transaction is either sealed or excluded, the mempool
+		// ones may be left out.
+		seen := make(map[common.Hash]bool)
+		for _, enc := range sb.ExecutableData.ExecutionPayload.Transactions {
+			var tx types.Transaction
+			if err := tx.UnmarshalBinary(enc); err != nil {
+				t.Fatalf("failed to decode payload transaction: %v", err)
+			}
+			seen[tx.Hash()] = true
+		}
+		for _, excluded := range sb.ExcludedTransactions {
+			seen[excluded.Hash] = true
+		}
+		given := txns
+		for _, bundle := range opts.Bundles {
+			given = append(given, bundle.Transactions...)
+		}
+		for _, tx := range given {
+			if !seen[tx.Hash()] {
+				t.Fatalf("transaction %s lost: neither sealed nor excluded", tx.Hash())
+			}
+		}
+		b.newPayload(t, sb)
+	})
+}
+
+// blobTx creates a blob transaction to a fixed address carrying the given
+// blob hashes, its sidecar left out.
+func blobTx(t testing.TB, key *ecdsa.PrivateKey, nonce uint64, hashes []common.Hash) *types.Transaction {
+	chainID := uint256.MustFromBig(params.AllEthashProtocolChanges.ChainID)
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(params.AllEthashProtocolChanges.ChainID), &types.BlobTx{
+		ChainID:    chainID,
+		Nonce:      nonce,
+		To:         common.Address{0xb1, 0x0b},
+		Gas:        params.TxGas,
+		GasTipCap:  uint256.NewInt(1),
+		GasFeeCap:  uint256.NewInt(2 * params.InitialBaseFee),
+		BlobFeeCap: uint256.NewInt(10),
+		BlobHashes: hashes,
+		Value:      uint256.NewInt(1),
+	})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	return tx
+}
@@ -0,0 +1,154 @@
+package sealer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+)
+
+// SimulatedTransaction is the outcome of an included transaction.
+type SimulatedTransaction struct {
+	Hash          common.Hash     `json:"hash"`
+	Status        hexutil.Uint64  `json:"status"`
+	GasUsed       hexutil.Uint64  `json:"gasUsed"`
+	Logs          []*types.Log    `json:"logs"`
+	CoinbaseDelta *hexutil.Big    `json:"coinbaseDelta"`
+	RevertReason  string          `json:"revertReason,omitempty"`
+	RevertData    hexutil.Bytes   `json:"revertData,omitempty"`
+	StateDiff     json.RawMessage `json:"stateDiff"`
+	Trace         json.RawMessage `json:"trace,omitempty"`
+}
+
+// SimulationResult is the outcome of a simulated seal request.
+type SimulationResult struct {
+	Transactions         []SimulatedTransaction `json:"txs"`
+	ExcludedTransactions []ExcludedTransaction  `json:"excludedTxns"`
+	GasUsed              hexutil.Uint64         `json:"gasUsed"`
+	Profit               *hexutil.Big           `json:"profit"`
+	PriorityFees         *hexutil.Big           `json:"priorityFees"`
+	DirectPayments       *hexutil.Big           `json:"directPayments"`
+	Partial              bool                   `json:"partial,omitempty"`
+	InterruptReason      string                 `json:"interruptReason,omitempty"`
+	MempoolRejections    map[string]int         `json:"mempoolRejections,omitempty"`
+}
+
+// Simulate executes the same request as SealBlock on top of the parent state,
+// optionally overridden, without assembling the block. The state diff of each
+// transaction is taken with the prestateTracer in diff mode.
+func (s *Sealer) Simulate(ctx context.Context, p *BlockParameters, txns []*types.Transaction, fillWithMempool bool, trace bool, opts *SealOptions, overrides *ethapi.StateOverride) (*SimulationResult, error) {
+	if err := s.checkRequest(p, txns, opts); err != nil {
+		return nil, err
+	}
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	env, err := s.prepareEnv(p, opts, overrides)
+	if err != nil {
+		return nil, err
+	}
+	defer env.stopPrefetcher()
+
+	// Run the requested tracer next to the prestateTracer in a muxTracer. The
+	// requested one is nested in a muxTracer of its own, so that it cannot
+	// clash with the internal prestateTracer.
+	var traced *traceSpec
+	if trace {
+		traced = callTracerSpec(s.config.TraceTimeout)
+	}
+	if opts != nil && opts.TraceConfig != nil {
+		if traced, err = newTraceSpec(opts.TraceConfig, s.config.TraceTimeout); err != nil {
+			return nil, err
+		}
+		if traced.config.Tracer == nil {
+			return nil, errors.New("simulation requires a named tracer")
+		}
+	}
+	muxConfig := map[string]json.RawMessage{"prestateTracer": json.RawMessage(`{"diffMode":true}`)}
+	if traced != nil {
+		tracerConfig := traced.config.TracerConfig
+		if tracerConfig == nil {
+			tracerConfig = json.RawMessage(`{}`)
+		}
+		requested, err := json.Marshal(map[string]json.RawMessage{*traced.config.Tracer: tracerConfig})
+		if err != nil {
+			return nil, err
+		}
+		muxConfig["muxTracer"] = requested
+	}
+	config, err := json.Marshal(muxConfig)
+	if err != nil {
+		return nil, err
+	}
+	env.tracer = namedTraceSpec("muxTracer", config, s.config.TraceTimeout)
+	if traced != nil {
+		env.tracer.timeout = traced.timeout
+	}
+
+	bundles, strategies, err := env.applyOptions(txns, opts)
+	if err != nil {
+		return nil, err
+	}
+	var deadline *uint64
+	if opts != nil {
+		deadline = opts.Deadline
+	}
+	defer env.startInterrupts(ctx, s.config.SealTimeout, deadline)()
+	if len(strategies) > 1 {
+		return nil, errors.New("simulation supports a single ordering strategy")
+	}
+
+	mempool := s.pending(env, fillWithMempool, txns, bundles, opts)
+
+	s.fill(env, orderingStrategies[strategies[0]].newStream(s, env, txns, bundles, mempool))
+
+	res := &SimulationResult{
+		Transactions:         make([]SimulatedTransaction, len(env.txs)),
+		ExcludedTransactions: env.excluded,
+		GasUsed:              hexutil.Uint64(env.header.GasUsed),
+		Partial:              env.interrupted != commitInterruptNone,
+		InterruptReason:      interruptReason(env.interrupted),
+		MempoolRejections:    env.rejected,
+	}
+	for i, receipt := range env.receipts {
+		var traces map[string]json.RawMessage
+		if err := json.Unmarshal(env.traces[i], &traces); err != nil {
+			return nil, fmt.Errorf("could not decode trace of transaction %s: %w", receipt.TxHash.Hex(), err)
+		}
+		logs := receipt.Logs
+		if logs == nil {
+			logs = []*types.Log{}
+		}
+		res.Transactions[i] = SimulatedTransaction{
+			Hash:          receipt.TxHash,
+			Status:        hexutil.Uint64(receipt.Status),
+			GasUsed:       hexutil.Uint64(receipt.GasUsed),
+			Logs:          logs,
+			CoinbaseDelta: env.profits[i].Profit,
+			StateDiff:     traces["prestateTracer"],
+		}
+		if traced != nil {
+			var requested map[string]json.RawMessage
+			if err := json.Unmarshal(traces["muxTracer"], &requested); err != nil {
+				return nil, fmt.Errorf("could not decode trace of transaction %s: %w", receipt.TxHash.Hex(), err)
+			}
+			res.Transactions[i].Trace = requested[*traced.config.Tracer]
+		}
+		if result := env.results[i]; result != nil && result.Failed() {
+			res.Transactions[i].RevertReason = executionError(result).Error()
+			res.Transactions[i].RevertData = result.Revert()
+		}
+	}
+
+	res.Profit, res.PriorityFees, res.DirectPayments = env.profit()
+
+	return res, nil
+}
@@ -0,0 +1,205 @@
+package sealer
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/miner"
+)
+
+const defaultStrategy = "callerFirst"
+
+// orderingStrategies are the strategies a candidate block can be built with.
+var orderingStrategies = map[string]orderingStrategy{
+	"callerFirst":  callerFirst{},
+	"priceOnly":    priceOnly{},
+	"bundleProfit": bundleProfit{},
+}
+
+// CandidateProfit is the profit of a candidate block built with one strategy.
+type CandidateProfit struct {
+	Strategy string       `json:"strategy"`
+	Profit   *hexutil.Big `json:"profit"`
+}
+
+// orderingStrategy decides in which order the transactions of a seal request
+// are offered to the block. The mempool map is owned by the strategy.
+type orderingStrategy interface {
+	newStream(s *Sealer, env *environment, txns []*types.Transaction, bundles []*Bundle, mempool map[common.Address][]*txpool.LazyTransaction) *txStream
+}
+
+// callerFirst includes the caller transactions in the given order, then the
+// bundles in the given order, then the mempool by price and nonce.
+type callerFirst struct{}
+
+func (callerFirst) newStream(s *Sealer, env *environment, txns []*types.Transaction, bundles []*Bundle, mempool map[common.Address][]*txpool.LazyTransaction) *txStream {
+	return newTxStream(s.signer, txns, bundles, miner.NewTransactionsByPriceAndNonce(s.signer, mempool, env.header.BaseFee))
+}
+
+// priceOnly includes the bundles, then merges the caller transactions into
+// the mempool and orders all of them by price and nonce. A caller transaction
+// replaces the mempool one with the same sender and nonce.
+//
+// A caller transaction with a nonce below the pending ones of its sender
+// cannot be merged: it is kept on top instead, so that its failure does not
+// drop the pending transactions of the sender.
+type priceOnly struct{}
+
+func (priceOnly) newStream(s *Sealer, env *environment, txns []*types.Transaction, bundles []*Bundle, mempool map[common.Address][]*txpool.LazyTransaction) *txStream {
+	var top []*types.Transaction
+	for _, tx := range txns {
+		from, err := types.Sender(s.signer, tx)
+		// Keep the transactions the price heap would silently drop on top, so
+		// that they are reported as excluded.
+		if err != nil || (env.header.BaseFee != nil && tx.GasFeeCapIntCmp(env.header.BaseFee) < 0) {
+			top = append(top, tx)
+			continue
+		}
+		merged, ok := mergeByNonce(mempool[from], tx)
+		if !ok {
+			top = append(top, tx)
+			continue
+		}
+		mempool[from] = merged
+	}
+	return newTxStream(s.signer, top, bundles, miner.NewTransactionsByPriceAndNonce(s.signer, mempool, env.header.BaseFee))
+}
+
+// mergeByNonce inserts tx into the nonce sorted list of one sender, replacing
+// any transaction with the same nonce. The pending nonces of a sender are
+// contiguous, so only the first transaction still in the pool is resolved to
+// find the position of tx. The list is returned unchanged, and false, if the
+// nonce of tx is below the pending ones.
+func mergeByNonce(list []*txpool.LazyTransaction, tx *types.Transaction) ([]*txpool.LazyTransaction, bool) {
+	ltx := &txpool.LazyTransaction{
+		Hash:      tx.Hash(),
+		Tx:        tx,
+		Time:      tx.Time(),
+		GasFeeCap: tx.GasFeeCap(),
+		GasTipCap: tx.GasTipCap(),
+		Gas:       tx.Gas(),
+		BlobGas:   tx.BlobGas(),
+	}
+	for i, pending := range list {
+		resolved := pending.Resolve()
+		if resolved == nil {
+			continue
+		}
+		pos := int64(tx.Nonce()) - int64(resolved.Nonce()) + int64(i)
+		if pos < 0 {
+			return list, false
+		}
+		merged := make([]*txpool.LazyTransaction, 0, len(list)+1)
+		if pos < int64(len(list)) {
+			merged = append(merged, list...)
+			merged[pos] = ltx
+		} else {
+			merged = append(append(merged, list...), ltx)
+		}
+		return merged, true
+	}
+	// Every pending transaction was evicted.
+	return []*txpool.LazyTransaction{ltx}, true
+}
+
+// bundleProfit includes the caller transactions, then the bundles sorted by
+// the profit each one makes at the top of the block, then the mempool.
+type bundleProfit struct{}
+
+func (bundleProfit) newStream(s *Sealer, env *environment, txns []*types.Transaction, bundles []*Bundle, mempool map[common.Address][]*txpool.LazyTransaction) *txStream {
+	// Simulate every bundle at the top of the block on a fresh copy of the
+	// state, reusing a single copy of the rest of the environment.
+	var (
+		profits = make(map[*Bundle]*big.Int, len(bundles))
+		sim     *environment
+	)
+	for _, bundle := range bundles {
+		if sim == nil {
+			sim = env.copy()
+			sim.tracer = nil
+		} else {
+			sim.state = env.state.Copy()
+			sim.gasPool.SetGas(env.gasPool.Gas())
+			sim.header.GasUsed = env.header.GasUsed
+			sim.blobs = env.blobs
+		}
+		if _, _, err := s.executeBundle(sim, bundle); err == nil {
+			profits[bundle] = sim.coinbaseDelta(env.coinbaseBalance())
+		}
+	}
+	sorted := append([]*Bundle{}, bundles...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, pj := profits[sorted[i]], profits[sorted[j]]
+		if pi == nil || pj == nil {
+			return pj == nil && pi != nil
+		}
+		return pi.Cmp(pj) > 0
+	})
+	return newTxStream(s.signer, txns, sorted, miner.NewTransactionsByPriceAndNonce(s.signer, mempool, env.header.BaseFee))
+}
+
+// sealCandidates builds one candidate block per strategy concurrently, each on
+// its own copy of env, and seals the most profitable one.
+func (s *Sealer) sealCandidates(env *environment, strategies []string, withdrawals []*types.Withdrawal, txns []*types.Transaction, bundles []*Bundle, mempool map[common.Address][]*txpool.LazyTransaction) (*SealedBlock, error) {
+	var (
+		envs = make([]*environment, len(strategies))
+		wg   sync.WaitGroup
+	)
+	for i, name := range strategies {
+		envs[i] = env.copy()
+		wg.Add(1)
+		go func(env *environment, strategy orderingStrategy, mempool map[common.Address][]*txpool.LazyTransaction) {
+			defer wg.Done()
+			s.fill(env, strategy.newStream(s, env, txns, bundles, mempool))
+		}(envs[i], orderingStrategies[name], copyPending(mempool))
+	}
+	wg.Wait()
+	env.state.StopPrefetcher()
+
+	var (
+		best       int
+		bestProfit *big.Int
+		candidates = make([]CandidateProfit, len(envs))
+	)
+	for i, env := range envs {
+		profit := env.coinbaseDelta(env.startBalance)
+		candidates[i] = CandidateProfit{Strategy: strategies[i], Profit: (*hexutil.Big)(profit)}
+		if bestProfit == nil || profit.Cmp(bestProfit) > 0 {
+			best, bestProfit = i, profit
+		}
+	}
+	// The prefetcher of the best candidate is stopped once it is finalized.
+	for i, env := range envs {
+		if i != best {
+			env.state.StopPrefetcher()
+		}
+	}
+
+	sb, err := s.finalize(envs[best], withdrawals)
+	if err != nil {
+		return nil, err
+	}
+	sb.Strategy = strategies[best]
+	sb.Candidates = candidates
+	return sb, nil
+}
+
+// copyPending copies the pending transactions so that each candidate can
+// iterate and resolve them independently.
+func copyPending(pending map[common.Address][]*txpool.LazyTransaction) map[common.Address][]*txpool.LazyTransaction {
+	cpy := make(map[common.Address][]*txpool.LazyTransaction, len(pending))
+	for addr, txs := range pending {
+		list := make([]*txpool.LazyTransaction, len(txs))
+		for i, ltx := range txs {
+			ltx := *ltx
+			list[i] = &ltx
+		}
+		cpy[addr] = list
+	}
+	return cpy
+}
@@ -0,0 +1,69 @@
+package sealer
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// countingPool resolves transactions by hash and counts the lookups.
+type countingPool struct {
+	txs     map[common.Hash]*types.Transaction
+	lookups int
+}
+
+func (p *countingPool) Get(hash common.Hash) *types.Transaction {
+	p.lookups++
+	return p.txs[hash]
+}
+
+// Tests that a caller transaction is merged at the position of its nonce,
+// resolving a single pending transaction, unless its nonce is below the
+// pending ones.
+func TestMergeByNonce(t *testing.T) {
+	signer := types.LatestSignerForChainID(params.TestChainConfig.ChainID)
+	tests := []struct {
+		nonce uint64
+		stale bool     // Whether the caller transaction is left out
+		want  []uint64 // Nonces of the merged list, the caller transaction priced 2
+	}{
+		{nonce: 3, stale: true, want: []uint64{4, 5, 6}},
+		{nonce: 4, want: []uint64{4, 5, 6}},
+		{nonce: 5, want: []uint64{4, 5, 6}},
+		{nonce: 6, want: []uint64{4, 5, 6}},
+		{nonce: 8, want: []uint64{4, 5, 6, 8}},
+	}
+	for _, tt := range tests {
+		pool := &countingPool{txs: make(map[common.Hash]*types.Transaction)}
+		var list []*txpool.LazyTransaction
+		for nonce := uint64(4); nonce < 7; nonce++ {
+			tx := signedPriceTx(t, signer, nonce, 1)
+			pool.txs[tx.Hash()] = tx
+			list = append(list, &txpool.LazyTransaction{Pool: pool, Hash: tx.Hash()})
+		}
+		caller := signedPriceTx(t, signer, tt.nonce, 2)
+
+		merged, ok := mergeByNonce(list, caller)
+		if ok == tt.stale {
+			t.Errorf("nonce %d: merged %v, want %v", tt.nonce, ok, !tt.stale)
+		}
+		if pool.lookups != 1 {
+			t.Errorf("nonce %d: resolved %d pending transactions, want 1", tt.nonce, pool.lookups)
+		}
+		if len(merged) != len(tt.want) {
+			t.Fatalf("nonce %d: wrong merged length: have %d, want %d", tt.nonce, len(merged), len(tt.want))
+		}
+		for i, ltx := range merged {
+			tx := ltx.Resolve()
+			if tx.Nonce() != tt.want[i] {
+				t.Errorf("nonce %d: merged transaction %d has nonce %d, want %d", tt.nonce, i, tx.Nonce(), tt.want[i])
+			}
+			if (tx.Nonce() == tt.nonce && !tt.stale) != (tx.Hash() == caller.Hash()) {
+				t.Errorf("nonce %d: merged transaction %d is not the expected one", tt.nonce, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package sealer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+
+	defaultRecommitInterval = 2 * time.Second
+	minRecommitInterval     = 100 * time.Millisecond
+)
+
+// SealedBlocks creates a subscription (sealer_subscribe "sealedBlocks") that
+// re-seals the block template on top of every new chain head and every
+// recommit interval, given in milliseconds. Only blocks with a higher profit
+// than the last one pushed for the same parent are sent.
+//
+// The parent hash of the template is replaced by the new head, and the
+// timestamp keeps the distance the template has to its own parent. With a
+// deadline, no more blocks are sealed once it has passed.
+func (s *Sealer) SealedBlocks(ctx context.Context, p *BlockParameters, txns []*types.Transaction, fillWithMempool bool, trace bool, opts *SealOptions, recommit *hexutil.Uint64) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if p == nil {
+		return nil, errors.New("missing block parameters")
+	}
+	if err := s.checkRequest(p, txns, opts); err != nil {
+		return nil, err
+	}
+	if opts != nil && opts.ParentHeader != nil {
+		return nil, errors.New("subscriptions follow the chain head, a parent header cannot be given")
+	}
+	// Subscribe to the chain heads before reading the current one, so that no
+	// head is missed in between.
+	heads := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	headSub := s.chain.SubscribeChainHeadEvent(heads)
+
+	parent := s.chain.CurrentBlock()
+	if p.ParentHash != (common.Hash{}) {
+		parent = s.chain.GetHeaderByHash(p.ParentHash)
+		if parent == nil {
+			headSub.Unsubscribe()
+			return nil, fmt.Errorf("could not find parent block %s", p.ParentHash.Hex())
+		}
+	}
+	if err := checkTimestamp(parent, p.Timestamp); err != nil {
+		headSub.Unsubscribe()
+		return nil, err
+	}
+	spacing := p.Timestamp - parent.Time
+
+	interval := defaultRecommitInterval
+	if recommit != nil {
+		interval = time.Duration(*recommit) * time.Millisecond
+		if interval < minRecommitInterval {
+			interval = minRecommitInterval
+		}
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	// Abort the seal in flight as soon as the subscription ends.
+	sealCtx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer cancel()
+		select {
+		case <-rpcSub.Err():
+		case <-notifier.Closed():
+		}
+	}()
+
+	go func() {
+		defer cancel()
+		defer headSub.Unsubscribe()
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		var best *big.Int
+		for {
+			select {
+			case ev := <-heads:
+				parent, best = ev.Block.Header(), nil
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(0)
+
+			case <-timer.C:
+				// The deadline of the template is absolute, every seal after
+				// it would be empty: stop re-sealing once it has passed.
+				if expired(opts) {
+					return
+				}
+				template := *p
+				template.ParentHash = parent.Hash()
+				template.Timestamp = parent.Time + spacing
+
+				sb, err := s.SealBlock(sealCtx, &template, txns, fillWithMempool, trace, opts)
+				if sealCtx.Err() != nil {
+					return
+				}
+				if err != nil {
+					log.Debug("Failed to seal block for subscription", "parent", parent.Hash(), "err", err)
+				} else if profit := sb.Profit.ToInt(); best == nil || profit.Cmp(best) > 0 {
+					best = profit
+					notifier.Notify(rpcSub.ID, sb)
+				}
+				if expired(opts) {
+					return
+				}
+				timer.Reset(interval)
+
+			case <-headSub.Err():
+				return
+			case <-sealCtx.Done():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// expired reports whether the deadline of the seal options has passed.
+func expired(opts *SealOptions) bool {
+	return opts != nil && opts.Deadline != nil && time.Now().UnixMilli() >= int64(*opts.Deadline)
+}
@@ -0,0 +1,96 @@
+package sealer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+
+	// Force-load the native tracers the sealer runs, to trigger registration
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
+)
+
+// traceSpec selects the tracer to run on the sealed transactions.
+type traceSpec struct {
+	config  *tracers.TraceConfig
+	timeout time.Duration
+}
+
+// newTraceSpec validates the trace config requested by the caller. The
+// timeout defaults to limit, and cannot exceed it.
+func newTraceSpec(config *tracers.TraceConfig, limit time.Duration) (*traceSpec, error) {
+	spec := &traceSpec{config: config, timeout: limit}
+	if config.Timeout != nil {
+		timeout, err := time.ParseDuration(*config.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		if timeout > limit {
+			return nil, fmt.Errorf("trace timeout %v exceeds the limit of %v", timeout, limit)
+		}
+		spec.timeout = timeout
+	}
+	return spec, nil
+}
+
+// namedTraceSpec returns the spec of a tracer registered in the directory.
+func namedTraceSpec(name string, config json.RawMessage, timeout time.Duration) *traceSpec {
+	return &traceSpec{
+		config:  &tracers.TraceConfig{Tracer: &name, TracerConfig: config},
+		timeout: timeout,
+	}
+}
+
+// callTracerSpec returns the spec of the call tracer run when tracing is
+// requested without a trace config.
+func callTracerSpec(timeout time.Duration) *traceSpec {
+	return namedTraceSpec("callTracer", json.RawMessage(`{}`), timeout)
+}
+
+// newTracer creates the tracer of one transaction. As in the debug API, the
+// struct logger is used when no tracer is named. The tracer is stopped once
+// the timeout of the spec elapses, the returned timer must be stopped after
+// the transaction.
+func (spec *traceSpec) newTracer(ctx *tracers.Context) (tracers.Tracer, *time.Timer, error) {
+	var (
+		tracer tracers.Tracer = logger.NewStructLogger(spec.config.Config)
+		err    error
+	)
+	if spec.config.Tracer != nil {
+		tracer, err = tracers.DefaultDirectory.New(*spec.config.Tracer, ctx, spec.config.TracerConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	timer := time.AfterFunc(spec.timeout, func() {
+		tracer.Stop(errors.New("execution timeout"))
+	})
+	return tracer, timer, nil
+}
+
+// traceError is the trace of a transaction whose tracer failed.
+type traceError struct {
+	Error string `json:"error"`
+}
+
+// traceResult returns the trace collected by tracer. The transaction is
+// already applied by then, so a tracer that failed or timed out does not fail
+// it: the failure is reported in the trace instead.
+func traceResult(tracer tracers.Tracer) json.RawMessage {
+	res, err := tracer.GetResult()
+	if err != nil {
+		res, _ = json.Marshal(traceError{Error: err.Error()})
+	}
+	return res
+}
+
+// name returns the name of the tracer for error messages.
+func (spec *traceSpec) name() string {
+	if spec.config.Tracer == nil {
+		return "struct logger"
+	}
+	return *spec.config.Tracer
+}
@@ -1,36 +1,121 @@
 package sealer
 
 import (
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/miner"
 )
 
+// txStream yields the top transactions first, then the bundles, then the
+// mempool transactions by price and nonce.
+//
+// Mempool transactions already taken from the stream, or whose nonce is used
+// by an included transaction of the same sender, are skipped. This covers the
+// caller transactions that duplicate or replace a pending one.
 type txStream struct {
 	topTransactions []*types.Transaction
+	bundles         []*Bundle
 	mempool         miner.TransactionsByPriceAndNonce
+
+	signer  types.Signer
+	current *types.Transaction        // Transaction returned by the last peek
+	seen    map[common.Hash]bool      // Transactions taken from the stream
+	nonces  map[common.Address]uint64 // Next nonce of the senders with included transactions
+
+	considered int // Mempool transactions resolved from the pool
 }
 
-func newTxStream(topTransactions []*types.Transaction, mempool miner.TransactionsByPriceAndNonce) *txStream {
+func newTxStream(signer types.Signer, topTransactions []*types.Transaction, bundles []*Bundle, mempool miner.TransactionsByPriceAndNonce) *txStream {
 	return &txStream{
 		topTransactions: topTransactions,
+		bundles:         bundles,
 		mempool:         mempool,
+		signer:          signer,
+		seen:            make(map[common.Hash]bool),
+		nonces:          make(map[common.Address]uint64),
+	}
+}
+
+// included records the transactions included in the block, so that mempool
+// transactions with the same or a lower nonce are skipped.
+func (ts *txStream) included(txs []*types.Transaction) {
+	for _, tx := range txs {
+		from, err := types.Sender(ts.signer, tx)
+		if err != nil {
+			continue
+		}
+		if next := tx.Nonce() + 1; next > ts.nonces[from] {
+			ts.nonces[from] = next
+		}
 	}
+}
 
+// peekBundle returns the next bundle once the top transactions are exhausted.
+func (ts *txStream) peekBundle() *Bundle {
+	if len(ts.topTransactions) > 0 || len(ts.bundles) == 0 {
+		return nil
+	}
+	return ts.bundles[0]
 }
 
+func (ts *txStream) popBundle() {
+	if len(ts.bundles) > 0 {
+		for _, tx := range ts.bundles[0].Transactions {
+			ts.seen[tx.Hash()] = true
+		}
+		ts.bundles = ts.bundles[1:]
+	}
+}
+
+// peek returns the next single transaction. Callers must drain the pending
+// bundles with peekBundle before the mempool transactions are returned.
 func (ts *txStream) peek() *types.Transaction {
 	if len(ts.topTransactions) > 0 {
-		return ts.topTransactions[0]
+		ts.current = ts.topTransactions[0]
+		return ts.current
 	}
+	ts.current = nil
 
-	ltx := ts.mempool.Peek()
-	if ltx == nil {
-		return nil
+	for {
+		ltx := ts.mempool.Peek()
+		if ltx == nil {
+			return nil
+		}
+		tx := ltx.Resolve()
+		if tx == nil {
+			// The transaction was evicted from the pool in the meantime, skip
+			// it rather than ending the stream.
+			ts.mempool.Pop()
+			continue
+		}
+		ts.considered++
+		if ts.stale(tx) {
+			// The next transaction of the sender may still be executable.
+			ts.mempool.Shift()
+			continue
+		}
+		ts.current = tx
+		return tx
 	}
-	return ltx.Resolve()
+}
+
+// stale reports whether a mempool transaction duplicates one already taken
+// from the stream, or has a nonce already used in the block.
+func (ts *txStream) stale(tx *types.Transaction) bool {
+	if ts.seen[tx.Hash()] {
+		return true
+	}
+	from, err := types.Sender(ts.signer, tx)
+	if err != nil {
+		// Leave it to the execution to reject the transaction.
+		return false
+	}
+	next, ok := ts.nonces[from]
+	return ok && tx.Nonce() < next
 }
 
 func (ts *txStream) pop() {
+	ts.taken()
 	if len(ts.topTransactions) > 0 {
 		ts.topTransactions = ts.topTransactions[1:]
 		return
@@ -39,9 +124,18 @@ func (ts *txStream) pop() {
 }
 
 func (ts *txStream) shift() {
+	ts.taken()
 	if len(ts.topTransactions) > 0 {
 		ts.topTransactions = ts.topTransactions[1:]
 		return
 	}
 	ts.mempool.Shift()
 }
+
+// taken marks the last peeked transaction as seen.
+func (ts *txStream) taken() {
+	if ts.current != nil {
+		ts.seen[ts.current.Hash()] = true
+		ts.current = nil
+	}
+}
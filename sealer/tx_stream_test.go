@@ -0,0 +1,268 @@
+package sealer
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+
+// testMempool is a TransactionsByPriceAndNonce serving transactions in order.
+type testMempool struct {
+	txs []*txpool.LazyTransaction
+}
+
+func (m *testMempool) Peek() *txpool.LazyTransaction {
+	if len(m.txs) == 0 {
+		return nil
+	}
+	return m.txs[0]
+}
+
+func (m *testMempool) Shift() { m.txs = m.txs[1:] }
+func (m *testMempool) Pop()   { m.txs = m.txs[1:] }
+
+// evictedPool resolves no transaction, as if all were evicted.
+type evictedPool struct{}
+
+func (evictedPool) Get(common.Hash) *types.Transaction { return nil }
+
+func newTestMempool(txs ...*types.Transaction) *testMempool {
+	m := new(testMempool)
+	for _, tx := range txs {
+		m.txs = append(m.txs, &txpool.LazyTransaction{Hash: tx.Hash(), Tx: tx})
+	}
+	return m
+}
+
+func unsignedTx(nonce uint64) *types.Transaction {
+	return types.NewTx(&types.LegacyTx{Nonce: nonce, To: &common.Address{}, Gas: params.TxGas, GasPrice: big.NewInt(1)})
+}
+
+func signedTx(t *testing.T, signer types.Signer, nonce uint64) *types.Transaction {
+	tx, err := types.SignNewTx(testKey, signer, &types.LegacyTx{Nonce: nonce, To: &common.Address{}, Gas: params.TxGas, GasPrice: big.NewInt(1)})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	return tx
+}
+
+func signedPriceTx(t *testing.T, signer types.Signer, nonce uint64, price int64) *types.Transaction {
+	tx, err := types.SignNewTx(testKey, signer, &types.LegacyTx{Nonce: nonce, To: &common.Address{}, Gas: params.TxGas, GasPrice: big.NewInt(price)})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	return tx
+}
+
+func TestTxStreamOrder(t *testing.T) {
+	var (
+		top     = []*types.Transaction{unsignedTx(0), unsignedTx(1)}
+		bundle  = &Bundle{Transactions: []*types.Transaction{unsignedTx(2)}}
+		mempool = unsignedTx(3)
+		stream  = newTxStream(types.HomesteadSigner{}, top, []*Bundle{bundle}, newTestMempool(mempool))
+	)
+	for _, want := range top {
+		if b := stream.peekBundle(); b != nil {
+			t.Fatalf("bundle returned before the top transactions")
+		}
+		if tx := stream.peek(); tx != want {
+			t.Fatalf("wrong top transaction: have %v, want %v", tx.Hash(), want.Hash())
+		}
+		stream.shift()
+	}
+	if b := stream.peekBundle(); b != bundle {
+		t.Fatalf("bundle not returned after the top transactions")
+	}
+	stream.popBundle()
+	if b := stream.peekBundle(); b != nil {
+		t.Fatalf("bundle returned twice")
+	}
+	if tx := stream.peek(); tx != mempool {
+		t.Fatalf("mempool transaction not returned after the bundles")
+	}
+	stream.pop()
+	if tx := stream.peek(); tx != nil {
+		t.Fatalf("stream not drained, have %v", tx.Hash())
+	}
+}
+
+func TestTxStreamSkipsEvicted(t *testing.T) {
+	tx := unsignedTx(1)
+	mempool := &testMempool{txs: []*txpool.LazyTransaction{
+		{Pool: evictedPool{}, Hash: common.Hash{0x01}},
+		{Hash: tx.Hash(), Tx: tx},
+	}}
+	stream := newTxStream(types.HomesteadSigner{}, nil, nil, mempool)
+	if have := stream.peek(); have != tx {
+		t.Fatalf("evicted transaction not skipped")
+	}
+}
+
+func TestTxStreamSkipsStaleNonces(t *testing.T) {
+	var (
+		signer   = types.LatestSignerForChainID(big.NewInt(1))
+		caller   = signedTx(t, signer, 1)
+		stale    = signedTx(t, signer, 0)
+		replaced = signedPriceTx(t, signer, 1, 2)
+		next     = signedTx(t, signer, 2)
+		stream   = newTxStream(signer, []*types.Transaction{caller}, nil, newTestMempool(stale, replaced, next))
+	)
+	if tx := stream.peek(); tx != caller {
+		t.Fatalf("caller transaction not returned first")
+	}
+	stream.shift()
+	stream.included([]*types.Transaction{caller})
+
+	// Both the lower and the replaced nonce are taken by the caller.
+	if tx := stream.peek(); tx != next {
+		t.Fatalf("wrong transaction after stale nonces: have %v, want %v", tx.Hash(), next.Hash())
+	}
+	stream.pop()
+	if tx := stream.peek(); tx != nil {
+		t.Fatalf("stream not drained, have %v", tx.Hash())
+	}
+}
+
+func TestTxStreamSkipsDuplicates(t *testing.T) {
+	var (
+		signer = types.LatestSignerForChainID(big.NewInt(1))
+		caller = signedTx(t, signer, 0)
+		bundle = signedTx(t, signer, 1)
+		next   = signedTx(t, signer, 2)
+		stream = newTxStream(signer, []*types.Transaction{caller}, []*Bundle{{Transactions: []*types.Transaction{bundle}}}, newTestMempool(caller, bundle, next))
+	)
+	// The caller transaction and the bundle are excluded, so their nonces
+	// are not used, but the mempool copies must not be executed again.
+	if tx := stream.peek(); tx != caller {
+		t.Fatalf("caller transaction not returned first")
+	}
+	stream.pop()
+	if b := stream.peekBundle(); b == nil {
+		t.Fatalf("bundle not returned after the top transactions")
+	}
+	stream.popBundle()
+	if tx := stream.peek(); tx != next {
+		t.Fatalf("wrong transaction after duplicates: have %v, want %v", tx.Hash(), next.Hash())
+	}
+}
+
+// newTestEnv creates an environment on top of an empty state, enough to run
+// the transactions that get rejected before execution.
+func newTestEnv(t *testing.T, number int64) *environment {
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	header := &types.Header{Number: big.NewInt(number), GasLimit: 30_000_000, Difficulty: common.Big0}
+	return &environment{
+		state:      statedb,
+		gasPool:    new(core.GasPool).AddGas(header.GasLimit),
+		header:     header,
+		callers:    make(map[common.Hash]bool),
+		revertible: make(map[common.Hash]bool),
+		reward:     big.NewInt(0),
+		timings:    new(SealTimings),
+		excluded:   []ExcludedTransaction{},
+	}
+}
+
+// Tests that transactions rejected before execution, from the caller or from
+// the mempool, advance the stream instead of being peeked forever.
+func TestFillRejectsMalformedTransactions(t *testing.T) {
+	config := *params.TestChainConfig
+	config.EIP155Block = big.NewInt(100)
+
+	var (
+		signer    = types.LatestSignerForChainID(config.ChainID)
+		s         = &Sealer{signer: signer, chainConfig: &config}
+		protected = signedTx(t, signer, 0)
+		top       = []*types.Transaction{unsignedTx(0), protected}
+		mempool   = []*types.Transaction{unsignedTx(1), signedTx(t, signer, 1)}
+	)
+	env := newTestEnv(t, 1)
+	for _, tx := range top {
+		env.callers[tx.Hash()] = true
+	}
+	stream := newTxStream(signer, top, nil, newTestMempool(mempool...))
+
+	done := make(chan struct{})
+	go func() {
+		s.fill(env, stream)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("filling the block did not terminate")
+	}
+	want := append(top, mempool...)
+	if len(env.excluded) != len(want) {
+		t.Fatalf("wrong number of excluded transactions: have %d, want %d", len(env.excluded), len(want))
+	}
+	for i, tx := range want {
+		if env.excluded[i].Hash != tx.Hash() {
+			t.Errorf("excluded transaction %d: have %v, want %v", i, env.excluded[i].Hash, tx.Hash())
+		}
+	}
+	if len(env.txs) != 0 {
+		t.Errorf("malformed transactions included: %d", len(env.txs))
+	}
+}
+
+// Tests that an interrupted request stops filling the block.
+func TestFillInterrupted(t *testing.T) {
+	var (
+		signer = types.LatestSignerForChainID(params.TestChainConfig.ChainID)
+		s      = &Sealer{signer: signer, chainConfig: params.TestChainConfig}
+		env    = newTestEnv(t, 1)
+	)
+	env.interrupt = new(atomic.Int32)
+	env.interrupt.Store(commitInterruptTimeout)
+
+	s.fill(env, newTxStream(signer, []*types.Transaction{unsignedTx(0)}, nil, newTestMempool()))
+	if len(env.excluded) != 0 {
+		t.Fatalf("interrupted request kept filling the block")
+	}
+	if env.interrupted != commitInterruptTimeout {
+		t.Fatalf("wrong interrupt signal: have %d, want %d", env.interrupted, commitInterruptTimeout)
+	}
+}
+
+// Tests that canceling the request context or passing the deadline interrupts
+// the request.
+func TestStartInterrupts(t *testing.T) {
+	env := newTestEnv(t, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := env.startInterrupts(ctx, DefaultConfig.SealTimeout, nil)
+	defer stop()
+
+	cancel()
+	waitSignal(t, env.interrupt, commitInterruptCanceled)
+
+	deadline := uint64(time.Now().Add(-time.Second).UnixMilli())
+	defer env.startInterrupts(context.Background(), DefaultConfig.SealTimeout, &deadline)()
+	waitSignal(t, env.interrupt, commitInterruptDeadline)
+}
+
+func waitSignal(t *testing.T, interrupt *atomic.Int32, want int32) {
+	t.Helper()
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+		if interrupt.Load() == want {
+			return
+		}
+	}
+	t.Fatalf("interrupt not fired: have %d, want %d", interrupt.Load(), want)
+}
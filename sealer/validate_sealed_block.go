@@ -0,0 +1,137 @@
+package sealer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// sealedBlock is a sealed block as assembled, and as returned to the caller.
+// Its post-state is kept to seal further blocks on top of it, and must only
+// be read through copies.
+//
+// If the block was sealed on top of a parent header supplied by the caller, the
+// parent and its state are kept to validate the block.
+type sealedBlock struct {
+	block    *types.Block
+	envelope *engine.ExecutionPayloadEnvelope
+	state    *state.StateDB
+
+	parent      *types.Header
+	parentState *state.StateDB
+}
+
+// SealedBlockStatus is the outcome of validating or importing a sealed block.
+type SealedBlockStatus struct {
+	Hash            common.Hash `json:"hash"`
+	Valid           bool        `json:"valid"`
+	ValidationError *string     `json:"validationError"`
+}
+
+func newSealedBlockStatus(hash common.Hash, err error) *SealedBlockStatus {
+	status := &SealedBlockStatus{Hash: hash, Valid: err == nil}
+	if err != nil {
+		msg := err.Error()
+		status.ValidationError = &msg
+	}
+	return status
+}
+
+// payloadBlock returns the cached sealed block with the given hash, decoded
+// back from the payload returned to the caller like engine_newPayload does.
+func (s *Sealer) payloadBlock(hash common.Hash) (*types.Block, error) {
+	sealed, ok := s.sealed.Get(hash)
+	if !ok {
+		return nil, fmt.Errorf("unknown sealed block %s", hash.Hex())
+	}
+	var versionedHashes []common.Hash
+	for _, tx := range sealed.block.Transactions() {
+		versionedHashes = append(versionedHashes, tx.BlobHashes()...)
+	}
+	block, err := engine.ExecutableDataToBlock(*sealed.envelope.ExecutionPayload, versionedHashes, sealed.block.BeaconRoot())
+	if err != nil {
+		return nil, err
+	}
+	if block.Hash() != hash {
+		return nil, fmt.Errorf("payload block hash %s mismatches sealed block", block.Hash().Hex())
+	}
+	return block, nil
+}
+
+// ValidateSealedBlock runs the full block validation, header, body and state
+// after re-execution, against a block previously returned by SealBlock.
+func (s *Sealer) ValidateSealedBlock(ctx context.Context, hash common.Hash) (*SealedBlockStatus, error) {
+	sealed, ok := s.sealed.Get(hash)
+	if !ok {
+		return nil, fmt.Errorf("unknown sealed block %s", hash.Hex())
+	}
+	block, err := s.payloadBlock(hash)
+	if err != nil {
+		return newSealedBlockStatus(hash, err), nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return newSealedBlockStatus(hash, s.validateBlock(block, sealed)), nil
+}
+
+// validateBlock validates a sealed block on top of its parent, imported,
+// sealed earlier or supplied by the caller.
+func (s *Sealer) validateBlock(block *types.Block, sealed *sealedBlock) error {
+	var (
+		chain   = &speculativeChain{BlockChain: s.chain, sealer: s, parent: sealed.parent}
+		parent  *types.Header
+		statedb *state.StateDB
+		err     error
+	)
+	if sealed.parent != nil && sealed.parent.Hash() == block.ParentHash() {
+		parent, statedb = sealed.parent, sealed.parentState.Copy()
+	} else if parent, statedb, err = s.parentByHash(block.ParentHash(), block.NumberU64()-1); err != nil {
+		return err
+	}
+	if err := s.engine.VerifyHeader(chain, block.Header()); err != nil {
+		return err
+	}
+	if err := s.chain.Validator().ValidateBody(block); err != nil {
+		switch {
+		case errors.Is(err, core.ErrKnownBlock):
+			// An imported block was validated on insertion, provided it is
+			// the same block.
+			if known := s.chain.GetBlock(block.Hash(), block.NumberU64()); known != nil && known.Hash() == block.Hash() {
+				return nil
+			}
+		case errors.Is(err, consensus.ErrUnknownAncestor) && !s.imported(parent):
+			// The ancestor is checked last, the body is otherwise valid.
+		default:
+			return err
+		}
+	}
+	receipts, _, usedGas, err := s.chain.Processor().Process(block, statedb, *s.chain.GetVMConfig())
+	if err != nil {
+		return err
+	}
+	return s.chain.Validator().ValidateState(block, statedb, receipts, usedGas)
+}
+
+// ImportSealedBlock inserts a block previously returned by SealBlock into the
+// chain as a side-chain block, without making it the head.
+func (s *Sealer) ImportSealedBlock(ctx context.Context, hash common.Hash) (*SealedBlockStatus, error) {
+	if _, ok := s.sealed.Get(hash); !ok {
+		return nil, fmt.Errorf("unknown sealed block %s", hash.Hex())
+	}
+	block, err := s.payloadBlock(hash)
+	if err != nil {
+		return newSealedBlockStatus(hash, err), nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return newSealedBlockStatus(hash, s.chain.InsertBlockWithoutSetHead(block)), nil
+}